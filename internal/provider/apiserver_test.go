@@ -0,0 +1,744 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+	restclient "k8s.io/client-go/rest"
+	"sigs.k8s.io/yaml"
+)
+
+// fakeResource is a resource served by fakeAPIServer.
+type fakeResource struct {
+	gvk          schema.GroupVersionKind
+	name         string
+	namespaced   bool
+	subresources []string
+}
+
+// fakeResources are the resources served by fakeAPIServer: built-in kinds of
+// both scopes, one with status and scale subresources, service accounts
+// issuing tokens, and a custom resource without strategic merge patch
+// support.
+var fakeResources = []fakeResource{
+	{gvk: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, name: "configmaps", namespaced: true},
+	{gvk: schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, name: "namespaces"},
+	{gvk: schema.GroupVersionKind{Version: "v1", Kind: "ServiceAccount"}, name: "serviceaccounts", namespaced: true, subresources: []string{"token"}},
+	{gvk: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, name: "deployments", namespaced: true, subresources: []string{"status", "scale"}},
+	{gvk: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, name: "clusterroles"},
+	{gvk: schema.GroupVersionKind{Group: "example.com", Version: "v1", Kind: "Widget"}, name: "widgets", namespaced: true, subresources: []string{"status"}},
+}
+
+// fakeAPIServer is an in-process Kubernetes API server speaking enough of the
+// discovery and REST protocols for the provider: getting, listing and
+// patching objects with every patch type, subresources and dry-runs.
+//
+// Server-side apply is simplified: a field manager owns the leaf fields it
+// applied, fields it stops applying are removed, and applying a different
+// value to a field owned by another manager conflicts unless forced.
+type fakeAPIServer struct {
+	*httptest.Server
+
+	mu              sync.Mutex
+	objects         map[string]*unstructured.Unstructured
+	owners          map[string]map[string][]string
+	resourceVersion int
+	failures        []int
+	patchDelay      time.Duration
+	patchDrops      int
+	requests        []string
+	tokenRequests   []authenticationv1.TokenRequest
+}
+
+// newFakeAPIServer starts a fakeAPIServer serving objects, which is stopped
+// when the test ends.
+func newFakeAPIServer(t *testing.T, objects ...*unstructured.Unstructured) *fakeAPIServer {
+	s := &fakeAPIServer{
+		objects: map[string]*unstructured.Unstructured{},
+		owners:  map[string]map[string][]string{},
+	}
+	for _, obj := range objects {
+		s.add(t, obj)
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// client returns a KubernetesClient for the server, retrying requests with
+// testRetryPolicy and without client-side throttling.
+func (s *fakeAPIServer) client(t *testing.T) *KubernetesClient {
+	cfg := &restclient.Config{Host: s.URL, QPS: -1}
+	cfg.Wrap(newRetryTransport(testRetryPolicy))
+
+	client, err := NewKubernetesClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+// providerData returns the provider data configuring resources to use the
+// server, as a provider configured with its host would.
+func (s *fakeAPIServer) providerData(t *testing.T) *ProviderData {
+	return &ProviderData{
+		Client:  s.client(t),
+		Clients: newClientCache(KubernetesPatchProviderModel{Host: types.StringValue(s.URL)}, testRetryPolicy),
+		Retry:   testRetryPolicy,
+	}
+}
+
+// add stores obj, or replaces the stored object of the same name.
+func (s *fakeAPIServer) add(t *testing.T, obj *unstructured.Unstructured) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resource, ok := fakeResourceFor(obj.GroupVersionKind())
+	if !ok {
+		t.Fatalf("fake API server does not serve %s", obj.GroupVersionKind())
+	}
+
+	obj = obj.DeepCopy()
+	key := fakeObjectKey(resource, obj.GetNamespace(), obj.GetName())
+	if existing, ok := s.objects[key]; ok {
+		obj.SetUID(existing.GetUID())
+		obj.SetGeneration(existing.GetGeneration() + 1)
+	} else {
+		obj.SetUID(k8stypes.UID(fmt.Sprintf("uid-%s", strings.ReplaceAll(key, "/", "-"))))
+		obj.SetGeneration(1)
+	}
+	s.store(key, obj)
+}
+
+// get returns the stored object, or nil.
+func (s *fakeAPIServer) get(gvk schema.GroupVersionKind, namespace, name string) *unstructured.Unstructured {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resource, _ := fakeResourceFor(gvk)
+	if obj, ok := s.objects[fakeObjectKey(resource, namespace, name)]; ok {
+		return obj.DeepCopy()
+	}
+	return nil
+}
+
+// delete removes the stored object.
+func (s *fakeAPIServer) delete(gvk schema.GroupVersionKind, namespace, name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resource, _ := fakeResourceFor(gvk)
+	delete(s.objects, fakeObjectKey(resource, namespace, name))
+}
+
+// fail makes the server answer the next requests with statuses, in order.
+func (s *fakeAPIServer) fail(statuses ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failures = append(s.failures, statuses...)
+}
+
+// delayPatches makes the server answer PATCH requests other than dry-runs
+// after delay, unless the client gives up first, leaving the object as it is.
+func (s *fakeAPIServer) delayPatches(delay time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.patchDelay = delay
+}
+
+// dropPatches makes the server apply the next n PATCH requests other than
+// dry-runs and then close their connections without answering, as when the
+// connection is lost after the API server committed the change.
+func (s *fakeAPIServer) dropPatches(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.patchDrops = n
+}
+
+// issuedTokens returns the TokenRequests the server answered so far.
+func (s *fakeAPIServer) issuedTokens() []authenticationv1.TokenRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]authenticationv1.TokenRequest(nil), s.tokenRequests...)
+}
+
+// patches returns the PATCH requests received so far, as "<path>?<query>".
+func (s *fakeAPIServer) patches() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var patches []string
+	for _, r := range s.requests {
+		if p, ok := strings.CutPrefix(r, http.MethodPatch+" "); ok {
+			patches = append(patches, p)
+		}
+	}
+	return patches
+}
+
+func (s *fakeAPIServer) store(key string, obj *unstructured.Unstructured) {
+	s.resourceVersion++
+	obj.SetResourceVersion(strconv.Itoa(s.resourceVersion))
+	s.objects[key] = obj
+}
+
+func fakeResourceFor(gvk schema.GroupVersionKind) (fakeResource, bool) {
+	for _, r := range fakeResources {
+		if r.gvk == gvk {
+			return r, true
+		}
+	}
+	return fakeResource{}, false
+}
+
+func fakeObjectKey(resource fakeResource, namespace, name string) string {
+	return resource.gvk.GroupVersion().String() + "/" + resource.name + "/" + targetKey(namespace, name)
+}
+
+func (s *fakeAPIServer) serve(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	delay := s.patchDelay
+	s.mu.Unlock()
+	if r.Method == http.MethodPatch && !containsString(r.URL.Query()["dryRun"], metav1.DryRunAll) && delay > 0 {
+		// The request is only cancelled when the client disconnects once
+		// the body has been read.
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		select {
+		case <-r.Context().Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery)
+
+	if len(s.failures) > 0 {
+		status := s.failures[0]
+		s.failures = s.failures[1:]
+		writeStatus(w, status, "", "injected failure", nil)
+		return
+	}
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	var gv schema.GroupVersion
+	var rest []string
+	switch {
+	case r.URL.Path == "/api":
+		writeJSON(w, http.StatusOK, metav1.APIVersions{TypeMeta: metav1.TypeMeta{Kind: "APIVersions"}, Versions: []string{"v1"}})
+		return
+	case r.URL.Path == "/apis":
+		writeJSON(w, http.StatusOK, fakeGroupList())
+		return
+	case segments[0] == "api" && len(segments) >= 2:
+		gv, rest = schema.GroupVersion{Version: segments[1]}, segments[2:]
+	case segments[0] == "apis" && len(segments) >= 3:
+		gv, rest = schema.GroupVersion{Group: segments[1], Version: segments[2]}, segments[3:]
+	default:
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "the server could not find the requested resource", nil)
+		return
+	}
+
+	if len(rest) == 0 {
+		writeJSON(w, http.StatusOK, fakeResourceList(gv))
+		return
+	}
+
+	namespace := ""
+	if rest[0] == "namespaces" && len(rest) >= 3 {
+		namespace, rest = rest[1], rest[2:]
+	}
+
+	var resource fakeResource
+	found := false
+	for _, candidate := range fakeResources {
+		if candidate.gvk.GroupVersion() == gv && candidate.name == rest[0] {
+			resource, found = candidate, true
+		}
+	}
+	if !found || len(rest) > 3 {
+		writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "the server could not find the requested resource", nil)
+		return
+	}
+
+	switch {
+	case len(rest) == 1 && r.Method == http.MethodGet:
+		s.list(w, r, resource, namespace)
+	case len(rest) == 3 && rest[2] == "token" && r.Method == http.MethodPost && containsString(resource.subresources, "token"):
+		if _, ok := s.objects[fakeObjectKey(resource, namespace, rest[1])]; !ok {
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("%s %q not found", resource.name, rest[1]), nil)
+			return
+		}
+		s.createToken(w, r, namespace, rest[1])
+	case len(rest) >= 2 && (r.Method == http.MethodGet || r.Method == http.MethodPatch):
+		subresource := ""
+		if len(rest) == 3 {
+			subresource = rest[2]
+			if !containsString(resource.subresources, subresource) {
+				writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, "the server could not find the requested resource", nil)
+				return
+			}
+		}
+
+		key := fakeObjectKey(resource, namespace, rest[1])
+		obj, ok := s.objects[key]
+		if !ok {
+			writeStatus(w, http.StatusNotFound, metav1.StatusReasonNotFound, fmt.Sprintf("%s %q not found", resource.name, rest[1]), nil)
+			return
+		}
+
+		if r.Method == http.MethodGet {
+			writeJSON(w, http.StatusOK, fakeView(obj, subresource).Object)
+			return
+		}
+		s.patch(w, r, resource, key, obj, subresource)
+	default:
+		writeStatus(w, http.StatusMethodNotAllowed, metav1.StatusReasonMethodNotAllowed, "method not allowed", nil)
+	}
+}
+
+func (s *fakeAPIServer) list(w http.ResponseWriter, r *http.Request, resource fakeResource, namespace string) {
+	labelSelector, err := labels.Parse(r.URL.Query().Get("labelSelector"))
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error(), nil)
+		return
+	}
+	fieldSelector, err := fields.ParseSelector(r.URL.Query().Get("fieldSelector"))
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error(), nil)
+		return
+	}
+
+	prefix := fakeObjectKey(resource, "", "")
+	var keys []string
+	for key, obj := range s.objects {
+		if !strings.HasPrefix(key, prefix) || (namespace != "" && obj.GetNamespace() != namespace) {
+			continue
+		}
+		if !labelSelector.Matches(labels.Set(obj.GetLabels())) {
+			continue
+		}
+		if !fieldSelector.Matches(fields.Set{"metadata.name": obj.GetName(), "metadata.namespace": obj.GetNamespace()}) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	items := make([]any, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, s.objects[key].Object)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"apiVersion": resource.gvk.GroupVersion().String(),
+		"kind":       resource.gvk.Kind + "List",
+		"metadata":   map[string]any{"resourceVersion": strconv.Itoa(s.resourceVersion)},
+		"items":      items,
+	})
+}
+
+func (s *fakeAPIServer) patch(w http.ResponseWriter, r *http.Request, resource fakeResource, key string, obj *unstructured.Unstructured, subresource string) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error(), nil)
+		return
+	}
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	query := r.URL.Query()
+
+	view := fakeView(obj, subresource)
+	original, err := view.MarshalJSON()
+	if err != nil {
+		writeStatus(w, http.StatusInternalServerError, metav1.StatusReasonInternalError, err.Error(), nil)
+		return
+	}
+
+	var patched []byte
+	var owned []string
+	manager := query.Get("fieldManager")
+	switch k8stypes.PatchType(contentType) {
+	case k8stypes.JSONPatchType:
+		var p jsonpatch.Patch
+		p, err = jsonpatch.DecodePatch(body)
+		if err == nil {
+			patched, err = p.Apply(original)
+		}
+	case k8stypes.MergePatchType:
+		patched, err = jsonpatch.MergePatch(original, body)
+	case k8stypes.StrategicMergePatchType:
+		dataStruct, ok := strategicDataStruct(view.GroupVersionKind())
+		if !ok {
+			writeStatus(w, http.StatusUnsupportedMediaType, metav1.StatusReasonUnsupportedMediaType, "the body of the request was in an unknown format", nil)
+			return
+		}
+		patched, err = strategicpatch.StrategicMergePatch(original, body, dataStruct)
+	case k8stypes.ApplyYAMLPatchType:
+		var conflicts []metav1.StatusCause
+		patched, owned, conflicts, err = s.apply(key, original, body, manager, query.Get("force") == "true")
+		if len(conflicts) > 0 {
+			writeStatus(w, http.StatusConflict, metav1.StatusReasonConflict, fmt.Sprintf("Apply failed with %d conflicts", len(conflicts)), conflicts)
+			return
+		}
+	default:
+		writeStatus(w, http.StatusUnsupportedMediaType, metav1.StatusReasonUnsupportedMediaType, fmt.Sprintf("unsupported patch type %q", contentType), nil)
+		return
+	}
+	if err != nil {
+		writeStatus(w, http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, err.Error(), nil)
+		return
+	}
+
+	result := &unstructured.Unstructured{}
+	if err := result.UnmarshalJSON(patched); err != nil {
+		writeStatus(w, http.StatusUnprocessableEntity, metav1.StatusReasonInvalid, err.Error(), nil)
+		return
+	}
+	if rv := result.GetResourceVersion(); rv != "" && rv != obj.GetResourceVersion() {
+		writeStatus(w, http.StatusConflict, metav1.StatusReasonConflict, "the object has been modified; please apply your changes to the latest version and try again", nil)
+		return
+	}
+
+	updated := obj.DeepCopy()
+	switch subresource {
+	case "scale":
+		replicas, _, _ := unstructured.NestedInt64(result.Object, "spec", "replicas")
+		_ = unstructured.SetNestedField(updated.Object, replicas, "spec", "replicas")
+	case "status":
+		updated.Object["status"] = result.Object["status"]
+	default:
+		// Metadata the API server manages is kept, as is the status of
+		// resources with a status subresource.
+		status, hasStatus := updated.Object["status"]
+		identity := updated.DeepCopy()
+		updated = result
+		updated.SetName(identity.GetName())
+		updated.SetNamespace(identity.GetNamespace())
+		updated.SetUID(identity.GetUID())
+		updated.SetGeneration(identity.GetGeneration())
+		updated.SetResourceVersion(identity.GetResourceVersion())
+		if containsString(resource.subresources, "status") {
+			delete(updated.Object, "status")
+			if hasStatus {
+				updated.Object["status"] = status
+			}
+		}
+	}
+	if !reflect.DeepEqual(updated.Object["spec"], obj.Object["spec"]) {
+		updated.SetGeneration(obj.GetGeneration() + 1)
+	}
+
+	if !containsString(query["dryRun"], metav1.DryRunAll) {
+		if !reflect.DeepEqual(updated.Object, obj.Object) {
+			s.store(key, updated)
+		}
+		if k8stypes.PatchType(contentType) == k8stypes.ApplyYAMLPatchType {
+			if s.owners[key] == nil {
+				s.owners[key] = map[string][]string{}
+			}
+			s.owners[key][manager] = owned
+		}
+		if s.patchDrops > 0 {
+			s.patchDrops--
+			if conn, _, err := http.NewResponseController(w).Hijack(); err == nil {
+				conn.Close()
+				return
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, fakeView(updated, subresource).Object)
+}
+
+// createToken answers a TokenRequest for the service account namespace/name
+// with a token naming it, expiring after the requested duration or an hour.
+func (s *fakeAPIServer) createToken(w http.ResponseWriter, r *http.Request, namespace, name string) {
+	// Typed clients may send the request as protobuf.
+	var tokenRequest authenticationv1.TokenRequest
+	body, err := io.ReadAll(r.Body)
+	if err == nil {
+		_, _, err = scheme.Codecs.UniversalDeserializer().Decode(body, nil, &tokenRequest)
+	}
+	if err != nil {
+		writeStatus(w, http.StatusBadRequest, metav1.StatusReasonBadRequest, err.Error(), nil)
+		return
+	}
+	s.tokenRequests = append(s.tokenRequests, tokenRequest)
+
+	expiration := int64(3600)
+	if tokenRequest.Spec.ExpirationSeconds != nil {
+		expiration = *tokenRequest.Spec.ExpirationSeconds
+	}
+	tokenRequest.Status = authenticationv1.TokenRequestStatus{
+		Token:               fmt.Sprintf("token-%s-%s-%d", namespace, name, len(s.tokenRequests)),
+		ExpirationTimestamp: metav1.NewTime(fakeTokenIssued.Add(time.Duration(expiration) * time.Second)),
+	}
+	writeJSON(w, http.StatusCreated, tokenRequest)
+}
+
+// fakeTokenIssued is when fakeAPIServer issues its tokens.
+var fakeTokenIssued = time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// apply applies the configuration in body to original on behalf of manager,
+// returning the leaf fields manager owns afterwards, and the conflicts with
+// other managers if any.
+func (s *fakeAPIServer) apply(key string, original, body []byte, manager string, force bool) ([]byte, []string, []metav1.StatusCause, error) {
+	b, err := yaml.YAMLToJSON(body)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var config, object map[string]any
+	if err := json.Unmarshal(b, &config); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := json.Unmarshal(original, &object); err != nil {
+		return nil, nil, nil, err
+	}
+
+	// Identity fields are part of every configuration and owned by nobody.
+	config = shallowCopy(config)
+	delete(config, "apiVersion")
+	delete(config, "kind")
+	if metadata, ok := config["metadata"].(map[string]any); ok {
+		metadata = shallowCopy(metadata)
+		for _, field := range []string{"name", "namespace", "resourceVersion"} {
+			delete(metadata, field)
+		}
+		config["metadata"] = metadata
+	}
+
+	applied := leafFields(config, "")
+
+	var conflicts []metav1.StatusCause
+	for other, fields := range s.owners[key] {
+		if other == manager {
+			continue
+		}
+		for _, field := range fields {
+			value, ok := applied[field]
+			if ok && !reflect.DeepEqual(value, leafFields(object, "")[field]) {
+				if force {
+					s.owners[key][other] = removeString(s.owners[key][other], field)
+					continue
+				}
+				conflicts = append(conflicts, metav1.StatusCause{
+					Type:    metav1.CauseTypeFieldManagerConflict,
+					Message: fmt.Sprintf("conflict with %q", other),
+					Field:   field,
+				})
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return nil, nil, conflicts, nil
+	}
+
+	// Fields the manager stops applying are removed, unless another
+	// manager owns them too.
+	for _, field := range s.owners[key][manager] {
+		if _, ok := applied[field]; ok {
+			continue
+		}
+		shared := false
+		for other, fields := range s.owners[key] {
+			shared = shared || (other != manager && containsString(fields, field))
+		}
+		if !shared {
+			removeLeafField(object, strings.Split(strings.TrimPrefix(field, "."), "."))
+		}
+	}
+
+	b, err = json.Marshal(object)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	patched, err := jsonpatch.MergePatch(b, configJSON)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	owned := make([]string, 0, len(applied))
+	for field := range applied {
+		owned = append(owned, field)
+	}
+	sort.Strings(owned)
+	return patched, owned, nil, nil
+}
+
+// leafFields flattens obj into its leaf fields, keyed by dotted path. Lists
+// are leaves.
+func leafFields(obj map[string]any, prefix string) map[string]any {
+	out := map[string]any{}
+	for k, v := range obj {
+		if m, ok := v.(map[string]any); ok && len(m) > 0 {
+			for field, value := range leafFields(m, prefix+"."+k) {
+				out[field] = value
+			}
+			continue
+		}
+		out[prefix+"."+k] = v
+	}
+	return out
+}
+
+func removeLeafField(obj map[string]any, path []string) {
+	if len(path) == 1 {
+		delete(obj, path[0])
+		return
+	}
+	if m, ok := obj[path[0]].(map[string]any); ok {
+		removeLeafField(m, path[1:])
+		if len(m) == 0 {
+			delete(obj, path[0])
+		}
+	}
+}
+
+func removeString(values []string, value string) []string {
+	var out []string
+	for _, v := range values {
+		if v != value {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// fakeView returns obj as served by subresource: the object itself, or an
+// autoscaling/v1 Scale for the scale subresource.
+func fakeView(obj *unstructured.Unstructured, subresource string) *unstructured.Unstructured {
+	if subresource != "scale" {
+		return obj.DeepCopy()
+	}
+
+	replicas, _, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	statusReplicas, _, _ := unstructured.NestedInt64(obj.Object, "status", "replicas")
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "autoscaling/v1",
+		"kind":       "Scale",
+		"metadata": map[string]any{
+			"name":            obj.GetName(),
+			"namespace":       obj.GetNamespace(),
+			"uid":             string(obj.GetUID()),
+			"resourceVersion": obj.GetResourceVersion(),
+		},
+		"spec":   map[string]any{"replicas": replicas},
+		"status": map[string]any{"replicas": statusReplicas},
+	}}
+}
+
+func fakeGroupList() metav1.APIGroupList {
+	list := metav1.APIGroupList{TypeMeta: metav1.TypeMeta{Kind: "APIGroupList", APIVersion: "v1"}}
+	seen := map[string]bool{}
+	for _, r := range fakeResources {
+		if r.gvk.Group == "" || seen[r.gvk.Group] {
+			continue
+		}
+		seen[r.gvk.Group] = true
+
+		version := metav1.GroupVersionForDiscovery{GroupVersion: r.gvk.GroupVersion().String(), Version: r.gvk.Version}
+		list.Groups = append(list.Groups, metav1.APIGroup{
+			Name:             r.gvk.Group,
+			Versions:         []metav1.GroupVersionForDiscovery{version},
+			PreferredVersion: version,
+		})
+	}
+	return list
+}
+
+func fakeResourceList(gv schema.GroupVersion) metav1.APIResourceList {
+	list := metav1.APIResourceList{TypeMeta: metav1.TypeMeta{Kind: "APIResourceList", APIVersion: "v1"}, GroupVersion: gv.String()}
+	for _, r := range fakeResources {
+		if r.gvk.GroupVersion() != gv {
+			continue
+		}
+
+		list.APIResources = append(list.APIResources, metav1.APIResource{
+			Name:       r.name,
+			Namespaced: r.namespaced,
+			Kind:       r.gvk.Kind,
+			Verbs:      metav1.Verbs{"get", "list", "patch"},
+		})
+		for _, sub := range r.subresources {
+			resource := metav1.APIResource{
+				Name:       r.name + "/" + sub,
+				Namespaced: r.namespaced,
+				Kind:       r.gvk.Kind,
+				Verbs:      metav1.Verbs{"get", "patch"},
+			}
+			switch sub {
+			case "scale":
+				resource.Group, resource.Version, resource.Kind = "autoscaling", "v1", "Scale"
+			case "token":
+				resource.Group, resource.Version, resource.Kind = "authentication.k8s.io", "v1", "TokenRequest"
+				resource.Verbs = metav1.Verbs{"create"}
+			}
+			list.APIResources = append(list.APIResources, resource)
+		}
+	}
+	return list
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeStatus(w http.ResponseWriter, code int, reason metav1.StatusReason, message string, causes []metav1.StatusCause) {
+	status := metav1.Status{
+		TypeMeta: metav1.TypeMeta{Kind: "Status", APIVersion: "v1"},
+		Status:   metav1.StatusFailure,
+		Message:  message,
+		Reason:   reason,
+		Code:     int32(code),
+	}
+	if causes != nil {
+		status.Details = &metav1.StatusDetails{Causes: causes}
+	}
+	writeJSON(w, code, status)
+}
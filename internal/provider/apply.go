@@ -0,0 +1,188 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+)
+
+// applyConfiguration turns data, a JSON or YAML partial object, into the
+// body of a server-side apply request. The identifying fields the API server
+// requires are filled in from the target when they are omitted.
+func applyConfiguration(data []byte, gvk schema.GroupVersionKind, namespace, name string) ([]byte, error) {
+	b, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode apply configuration: %w", err)
+	}
+
+	var obj map[string]any
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("apply configuration must be an object: %w", err)
+	}
+	if obj == nil {
+		obj = map[string]any{}
+	}
+
+	if _, ok := obj["apiVersion"]; !ok {
+		obj["apiVersion"] = gvk.GroupVersion().String()
+	}
+	if _, ok := obj["kind"]; !ok {
+		obj["kind"] = gvk.Kind
+	}
+
+	metadata, ok := obj["metadata"].(map[string]any)
+	if !ok {
+		metadata = map[string]any{}
+		obj["metadata"] = metadata
+	}
+	if _, ok := metadata["name"]; !ok {
+		metadata["name"] = name
+	}
+	if _, ok := metadata["namespace"]; !ok && namespace != "" {
+		metadata["namespace"] = namespace
+	}
+
+	return json.Marshal(obj)
+}
+
+// applyInEffect reports whether the applied configuration is still reflected
+// in the live object. Lists are compared as sets, since server-side apply
+// merges associative lists by key rather than by position.
+func applyInEffect(live map[string]any, data []byte, ignore MetadataFilter) (bool, string, error) {
+	b, err := yaml.YAMLToJSON(data)
+	if err != nil {
+		return false, "", fmt.Errorf("could not decode apply configuration: %w", err)
+	}
+
+	var config map[string]any
+	if err := json.Unmarshal(b, &config); err != nil {
+		return false, "", fmt.Errorf("apply configuration must be an object: %w", err)
+	}
+	delete(config, "apiVersion")
+	delete(config, "kind")
+
+	if path, ok := isSubset(ignore.strip(config), live, ""); !ok {
+		return false, fmt.Sprintf("%s does not have the applied value", path), nil
+	}
+	return true, "", nil
+}
+
+// isSubset reports whether every field of config is present in live with the
+// same value. On mismatch it returns the path of the first differing field.
+func isSubset(config, live any, path string) (string, bool) {
+	switch c := config.(type) {
+	case map[string]any:
+		l, ok := live.(map[string]any)
+		if !ok {
+			return path, false
+		}
+		for key, value := range c {
+			if p, ok := isSubset(value, l[key], path+"."+key); !ok {
+				return p, false
+			}
+		}
+		return "", true
+	case []any:
+		l, ok := live.([]any)
+		if !ok {
+			return path, false
+		}
+		for i, element := range c {
+			found := false
+			for _, candidate := range l {
+				if _, ok := isSubset(element, candidate, ""); ok {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return fmt.Sprintf("%s[%d]", path, i), false
+			}
+		}
+		return "", true
+	default:
+		if !reflect.DeepEqual(config, live) {
+			return path, false
+		}
+		return "", true
+	}
+}
+
+// applyConflictError is returned when a server-side apply conflicts with
+// other field managers of the object.
+type applyConflictError struct {
+	err           error
+	managedFields []metav1.ManagedFieldsEntry
+}
+
+func (e *applyConflictError) Error() string {
+	return describeApplyConflict(e.err, e.managedFields)
+}
+
+func (e *applyConflictError) Unwrap() error {
+	return e.err
+}
+
+var conflictManagerRegexp = regexp.MustCompile(`conflict with "([^"]+)"`)
+
+// isApplyConflict reports whether err is a server-side apply field conflict.
+func isApplyConflict(err error) bool {
+	status, ok := err.(apierrors.APIStatus)
+	if !ok || !apierrors.IsConflict(err) || status.Status().Details == nil {
+		return false
+	}
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type == metav1.CauseTypeFieldManagerConflict {
+			return true
+		}
+	}
+	return false
+}
+
+// describeApplyConflict lists the conflicting fields of err and the managers
+// that own them according to managedFields.
+func describeApplyConflict(err error, managedFields []metav1.ManagedFieldsEntry) string {
+	var b strings.Builder
+	b.WriteString("Server-side apply failed because other field managers own fields in the configuration.\n\nConflicting fields:\n")
+
+	managers := map[string]bool{}
+	if status, ok := err.(apierrors.APIStatus); ok && status.Status().Details != nil {
+		for _, cause := range status.Status().Details.Causes {
+			if cause.Type != metav1.CauseTypeFieldManagerConflict {
+				continue
+			}
+			fmt.Fprintf(&b, "  - %s: %s\n", cause.Field, cause.Message)
+			if m := conflictManagerRegexp.FindStringSubmatch(cause.Message); m != nil {
+				managers[m[1]] = true
+			}
+		}
+	}
+
+	b.WriteString("\nConflicting managers:\n")
+	for _, entry := range managedFields {
+		if !managers[entry.Manager] {
+			continue
+		}
+		fmt.Fprintf(&b, "  - %q (operation %s, api version %s", entry.Manager, entry.Operation, entry.APIVersion)
+		if entry.Subresource != "" {
+			fmt.Fprintf(&b, ", subresource %s", entry.Subresource)
+		}
+		if entry.Time != nil {
+			fmt.Fprintf(&b, ", last changed %s", entry.Time.UTC().Format("2006-01-02T15:04:05Z"))
+		}
+		b.WriteString(")\n")
+	}
+
+	b.WriteString("\nSet force = true to take ownership of these fields, or remove them from the configuration.")
+	return b.String()
+}
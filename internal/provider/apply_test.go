@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestApplyConfiguration(t *testing.T) {
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+	b, err := applyConfiguration([]byte("spec:\n  replicas: 3\n"), gvk, "default", "test")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	var actual map[string]any
+	if err := json.Unmarshal(b, &actual); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"name": "test", "namespace": "default"},
+		"spec":       map[string]any{"replicas": float64(3)},
+	}
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestApplyInEffect(t *testing.T) {
+	live := map[string]any{
+		"metadata": map[string]any{"name": "test"},
+		"spec": map[string]any{
+			"replicas": float64(3),
+			"ports": []any{
+				map[string]any{"name": "http", "port": float64(80), "protocol": "TCP"},
+				map[string]any{"name": "https", "port": float64(443), "protocol": "TCP"},
+			},
+		},
+	}
+
+	inEffect, _, err := applyInEffect(live, []byte("apiVersion: v1\nkind: Service\nspec:\n  ports:\n  - name: https\n    port: 443\n"), MetadataFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !inEffect {
+		t.Fatal("expected configuration to be in effect")
+	}
+
+	inEffect, reason, err := applyInEffect(live, []byte(`{"spec": {"replicas": 2}}`), MetadataFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if inEffect {
+		t.Fatal("expected configuration not to be in effect")
+	}
+	if !strings.Contains(reason, ".spec.replicas") {
+		t.Fatalf("expected reason to name .spec.replicas, got %q", reason)
+	}
+}
+
+func TestDescribeApplyConflict(t *testing.T) {
+	err := &apierrors.StatusError{ErrStatus: metav1.Status{
+		Status: metav1.StatusFailure,
+		Code:   409,
+		Reason: metav1.StatusReasonConflict,
+		Details: &metav1.StatusDetails{
+			Causes: []metav1.StatusCause{{
+				Type:    metav1.CauseTypeFieldManagerConflict,
+				Message: `conflict with "kube-controller-manager" using apps/v1`,
+				Field:   ".spec.replicas",
+			}},
+		},
+	}}
+
+	if !isApplyConflict(err) {
+		t.Fatal("expected error to be an apply conflict")
+	}
+
+	changed := metav1.NewTime(time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC))
+	description := describeApplyConflict(err, []metav1.ManagedFieldsEntry{
+		{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply, APIVersion: "apps/v1"},
+		{Manager: "kube-controller-manager", Operation: metav1.ManagedFieldsOperationUpdate, APIVersion: "apps/v1", Time: &changed},
+	})
+
+	if !strings.Contains(description, `"kube-controller-manager" (operation Update, api version apps/v1, last changed 2025-01-02T03:04:05Z)`) {
+		t.Fatalf("expected description to name the conflicting manager, got:\n%s", description)
+	}
+	if strings.Contains(description, `"kubectl"`) {
+		t.Fatalf("expected description not to name managers without conflicts, got:\n%s", description)
+	}
+}
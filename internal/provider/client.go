@@ -0,0 +1,194 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	restclient "k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/util/flowcontrol"
+)
+
+// KubernetesClient bundles the clients used to talk to the cluster. Objects
+// are patched through the dynamic client, so any resource served by the API
+// server, including custom resources, can be targeted.
+type KubernetesClient struct {
+	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+	Discovery discovery.CachedDiscoveryInterface
+	Mapper    *restmapper.DeferredDiscoveryRESTMapper
+}
+
+// NewKubernetesClient creates the clients for the given rest config. Discovery
+// is lazy, so no request is made until the first resource is resolved. The
+// clients share the client-side rate limit of cfg, unless its QPS is negative.
+func NewKubernetesClient(cfg *restclient.Config) (*KubernetesClient, error) {
+	cfg = restclient.CopyConfig(cfg)
+	if cfg.RateLimiter == nil && cfg.QPS >= 0 {
+		qps, burst := cfg.QPS, cfg.Burst
+		if qps == 0 {
+			qps = restclient.DefaultQPS
+		}
+		if burst == 0 {
+			burst = restclient.DefaultBurst
+		}
+		cfg.RateLimiter = loggingRateLimiter{flowcontrol.NewTokenBucketRateLimiter(qps, burst)}
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := memory.NewMemCacheClient(clientset.Discovery())
+
+	return &KubernetesClient{
+		Clientset: clientset,
+		Dynamic:   dynamicClient,
+		Discovery: cached,
+		Mapper:    restmapper.NewDeferredDiscoveryRESTMapper(cached),
+	}, nil
+}
+
+// throttleLogThreshold is the shortest client-side throttling delay reported.
+const throttleLogThreshold = 100 * time.Millisecond
+
+// loggingRateLimiter reports the delays client-side rate limiting adds to
+// requests, which otherwise look like a slow API server.
+type loggingRateLimiter struct {
+	flowcontrol.RateLimiter
+}
+
+func (l loggingRateLimiter) Wait(ctx context.Context) error {
+	start := time.Now()
+	err := l.RateLimiter.Wait(ctx)
+	if wait := time.Since(start); wait >= throttleLogThreshold {
+		tflog.Info(ctx, "Kubernetes API request delayed by client-side throttling", map[string]any{
+			"wait": wait.String(),
+			"qps":  l.QPS(),
+		})
+	}
+	return err
+}
+
+// RESTMapping resolves a resource to its REST mapping. resource may be a plain
+// plural name ("deployments"), or qualified with a group ("certificates.cert-manager.io")
+// or a version and group ("deployments.v1.apps"), the same way kubectl accepts it.
+// When apiVersion is set it takes precedence over any qualification in resource.
+func (c *KubernetesClient) RESTMapping(apiVersion, resource string) (*meta.RESTMapping, error) {
+	mapping, err := c.restMapping(apiVersion, resource)
+	if meta.IsNoMatchError(err) {
+		// The resource may have been installed after discovery was cached,
+		// for example a CRD created earlier in the same apply.
+		c.Mapper.Reset()
+		mapping, err = c.restMapping(apiVersion, resource)
+	}
+	return mapping, err
+}
+
+func (c *KubernetesClient) restMapping(apiVersion, resource string) (*meta.RESTMapping, error) {
+	var gvk schema.GroupVersionKind
+
+	if apiVersion != "" {
+		gv, err := schema.ParseGroupVersion(apiVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid api_version %q: %w", apiVersion, err)
+		}
+		gvk, err = c.Mapper.KindFor(gv.WithResource(strings.ToLower(resource)))
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var err error
+		gvk, err = c.kindFor(resource)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+}
+
+func (c *KubernetesClient) kindFor(resource string) (schema.GroupVersionKind, error) {
+	fullySpecified, groupResource := schema.ParseResourceArg(strings.ToLower(resource))
+	if fullySpecified != nil {
+		if gvk, err := c.Mapper.KindFor(*fullySpecified); err == nil {
+			return gvk, nil
+		}
+	}
+	return c.Mapper.KindFor(groupResource.WithVersion(""))
+}
+
+// KindMapping resolves the kind of the given API version to its REST mapping.
+func (c *KubernetesClient) KindMapping(apiVersion, kind string) (*meta.RESTMapping, error) {
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid api_version %q: %w", apiVersion, err)
+	}
+	return c.GroupKindMapping(gv.WithKind(kind).GroupKind(), gv.Version)
+}
+
+// GroupKindMapping resolves a group and kind to its REST mapping, using the
+// preferred version served by the cluster when version is empty.
+func (c *KubernetesClient) GroupKindMapping(gk schema.GroupKind, version string) (*meta.RESTMapping, error) {
+	var versions []string
+	if version != "" {
+		versions = []string{version}
+	}
+
+	mapping, err := c.Mapper.RESTMapping(gk, versions...)
+	if meta.IsNoMatchError(err) {
+		c.Mapper.Reset()
+		mapping, err = c.Mapper.RESTMapping(gk, versions...)
+	}
+	return mapping, err
+}
+
+// ResourceInterface returns the dynamic client for the mapped resource, scoped
+// to namespace when the resource is namespaced.
+func (c *KubernetesClient) ResourceInterface(mapping *meta.RESTMapping, namespace string) (dynamic.ResourceInterface, error) {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return c.Dynamic.Resource(mapping.Resource), nil
+	}
+	if namespace == "" {
+		return nil, fmt.Errorf("%s is namespaced, but no namespace was given", mapping.Resource.GroupResource())
+	}
+	return c.Dynamic.Resource(mapping.Resource).Namespace(namespace), nil
+}
+
+// Subresources returns the subresources of the mapped resource that discovery
+// reports as patchable, e.g. status and scale for deployments.
+func (c *KubernetesClient) Subresources(mapping *meta.RESTMapping) ([]string, error) {
+	list, err := c.Discovery.ServerResourcesForGroupVersion(mapping.Resource.GroupVersion().String())
+	if err != nil {
+		return nil, err
+	}
+
+	var subresources []string
+	for _, resource := range list.APIResources {
+		name, ok := strings.CutPrefix(resource.Name, mapping.Resource.Resource+"/")
+		if ok && slices.Contains(resource.Verbs, "patch") {
+			subresources = append(subresources, name)
+		}
+	}
+	return subresources, nil
+}
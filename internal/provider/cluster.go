@@ -0,0 +1,264 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	restclient "k8s.io/client-go/rest"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// ClusterModel describes the cluster block of resources, overriding the
+// provider's connection settings.
+type ClusterModel struct {
+	ConfigContext         types.String `tfsdk:"config_context"`
+	ConfigContextAuthInfo types.String `tfsdk:"config_context_auth_info"`
+	ConfigContextCluster  types.String `tfsdk:"config_context_cluster"`
+
+	Host     types.String `tfsdk:"host"`
+	Insecure types.Bool   `tfsdk:"insecure"`
+
+	TLSServerName        types.String `tfsdk:"tls_server_name"`
+	ClusterCACertificate types.String `tfsdk:"cluster_ca_certificate"`
+	ClientCertificate    types.String `tfsdk:"client_certificate"`
+	ClientKey            types.String `tfsdk:"client_key"`
+
+	Token    types.String `tfsdk:"token"`
+	Username types.String `tfsdk:"username"`
+	Password types.String `tfsdk:"password"`
+}
+
+// clusterBlock returns the schema of the cluster block. Changing the context
+// or host targets another cluster and replaces the resource, whereas
+// credentials may change in place.
+func clusterBlock() schema.ListNestedBlock {
+	replace := []planmodifier.String{
+		stringplanmodifier.RequiresReplace(),
+	}
+	return schema.ListNestedBlock{
+		MarkdownDescription: "Targets another cluster than the provider's. Settings override the provider configuration, and settings left unset are inherited from it, so a context of the provider's kubeconfig, or a host with its credentials, can be selected per resource. Resources resolving to the same connection settings share their client.",
+		Validators: []validator.List{
+			listvalidator.SizeAtMost(1),
+		},
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"config_context": schema.StringAttribute{
+					MarkdownDescription: "Context of the provider's kubeconfig to use. Requires the provider to load a kubeconfig through `config_path` or `config_paths`.",
+					Optional:            true,
+					PlanModifiers:       replace,
+				},
+				"config_context_auth_info": schema.StringAttribute{
+					MarkdownDescription: "User of the provider's kubeconfig to use with the context.",
+					Optional:            true,
+				},
+				"config_context_cluster": schema.StringAttribute{
+					MarkdownDescription: "Cluster of the provider's kubeconfig to use with the context.",
+					Optional:            true,
+					PlanModifiers:       replace,
+				},
+				"host": schema.StringAttribute{
+					MarkdownDescription: "The hostname (in form of URI) of the Kubernetes API server.",
+					Optional:            true,
+					PlanModifiers:       replace,
+				},
+				"insecure": schema.BoolAttribute{
+					MarkdownDescription: "Whether the server should be accessed without verifying the TLS certificate.",
+					Optional:            true,
+				},
+				"tls_server_name": schema.StringAttribute{
+					MarkdownDescription: "Server name passed to the server for SNI and used in the client to check server certificates against.",
+					Optional:            true,
+				},
+				"cluster_ca_certificate": schema.StringAttribute{
+					MarkdownDescription: "PEM-encoded root certificates bundle for TLS authentication.",
+					Optional:            true,
+				},
+				"client_certificate": schema.StringAttribute{
+					MarkdownDescription: "PEM-encoded client certificate for TLS authentication.",
+					Optional:            true,
+				},
+				"client_key": schema.StringAttribute{
+					MarkdownDescription: "PEM-encoded client certificate key for TLS authentication.",
+					Optional:            true,
+					Sensitive:           true,
+				},
+				"token": schema.StringAttribute{
+					MarkdownDescription: "Token to authenticate with.",
+					Optional:            true,
+					Sensitive:           true,
+				},
+				"username": schema.StringAttribute{
+					MarkdownDescription: "The username to use for HTTP basic authentication.",
+					Optional:            true,
+				},
+				"password": schema.StringAttribute{
+					MarkdownDescription: "The password to use for HTTP basic authentication.",
+					Optional:            true,
+					Sensitive:           true,
+				},
+			},
+		},
+	}
+}
+
+// known reports whether every setting of the cluster block is known.
+func (c ClusterModel) known() bool {
+	for _, v := range []interface{ IsUnknown() bool }{
+		c.ConfigContext, c.ConfigContextAuthInfo, c.ConfigContextCluster, c.Host, c.Insecure,
+		c.TLSServerName, c.ClusterCACertificate, c.ClientCertificate, c.ClientKey,
+		c.Token, c.Username, c.Password,
+	} {
+		if v.IsUnknown() {
+			return false
+		}
+	}
+	return true
+}
+
+// withCluster returns the provider configuration d with the settings of the
+// cluster block, if any, taking precedence.
+func (d KubernetesPatchProviderModel) withCluster(cluster []ClusterModel) KubernetesPatchProviderModel {
+	if len(cluster) == 0 {
+		return d
+	}
+
+	c := cluster[0]
+	for _, s := range []struct {
+		value  types.String
+		target *types.String
+	}{
+		{c.ConfigContext, &d.ConfigContext},
+		{c.ConfigContextAuthInfo, &d.ConfigContextAuthInfo},
+		{c.ConfigContextCluster, &d.ConfigContextCluster},
+		{c.Host, &d.Host},
+		{c.TLSServerName, &d.TLSServerName},
+		{c.ClusterCACertificate, &d.ClusterCACertificate},
+		{c.ClientCertificate, &d.ClientCertificate},
+		{c.ClientKey, &d.ClientKey},
+		{c.Token, &d.Token},
+		{c.Username, &d.Username},
+		{c.Password, &d.Password},
+	} {
+		if !s.value.IsNull() {
+			*s.target = s.value
+		}
+	}
+	if !c.Insecure.IsNull() {
+		d.Insecure = c.Insecure
+	}
+	return d
+}
+
+// clientCache creates the Kubernetes clients of the provider and of the
+// clusters resources target, reusing the client of every distinct rest
+// config so that resources targeting the same cluster share connections and
+// discovery.
+type clientCache struct {
+	config KubernetesPatchProviderModel
+	retry  retryPolicy
+
+	mu      sync.Mutex
+	clients map[string]*KubernetesClient
+}
+
+func newClientCache(config KubernetesPatchProviderModel, retry retryPolicy) *clientCache {
+	return &clientCache{
+		config:  config,
+		retry:   retry,
+		clients: map[string]*KubernetesClient{},
+	}
+}
+
+// client returns the client for the provider configuration overridden by
+// cluster. It returns a nil client, with a warning, when the configuration
+// is invalid.
+func (c *clientCache) client(cluster []ClusterModel) (*KubernetesClient, diag.Diagnostics) {
+	cfg, diags := initializeConfiguration(c.config.withCluster(cluster))
+	if cfg == nil {
+		return nil, diags
+	}
+
+	key, err := restConfigKey(cfg)
+	if err != nil {
+		diags.AddError("could not create kubernetes client", err.Error())
+		return nil, diags
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if client, ok := c.clients[key]; ok {
+		return client, diags
+	}
+
+	cfg.Wrap(newRetryTransport(c.retry))
+	client, err := NewKubernetesClient(cfg)
+	if err != nil {
+		diags.AddError("could not create kubernetes client", err.Error())
+		return nil, diags
+	}
+	c.clients[key] = client
+	return client, diags
+}
+
+// restConfigKey identifies the server and credentials of cfg.
+func restConfigKey(cfg *restclient.Config) (string, error) {
+	key := struct {
+		Host            string
+		APIPath         string
+		Username        string
+		Password        string
+		BearerToken     string
+		BearerTokenFile string
+		Impersonate     restclient.ImpersonationConfig
+		TLS             restclient.TLSClientConfig
+		AuthProvider    *clientcmdapi.AuthProviderConfig
+		ExecProvider    *clientcmdapi.ExecConfig
+		Proxy           string
+	}{
+		Host:            cfg.Host,
+		APIPath:         cfg.APIPath,
+		Username:        cfg.Username,
+		Password:        cfg.Password,
+		BearerToken:     cfg.BearerToken,
+		BearerTokenFile: cfg.BearerTokenFile,
+		Impersonate:     cfg.Impersonate,
+		TLS:             cfg.TLSClientConfig,
+		AuthProvider:    cfg.AuthProvider,
+		ExecProvider:    cfg.ExecProvider,
+	}
+	if cfg.Proxy != nil {
+		// The proxy is a function, known by the URL it returns for the host.
+		req, err := http.NewRequest(http.MethodGet, cfg.Host, nil)
+		if err != nil {
+			return "", err
+		}
+		proxy, err := cfg.Proxy(req)
+		if err != nil {
+			return "", err
+		}
+		if proxy != nil {
+			key.Proxy = proxy.String()
+		}
+	}
+
+	b, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
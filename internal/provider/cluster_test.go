@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// testClusterModel returns a cluster block with every setting null.
+func testClusterModel() ClusterModel {
+	return ClusterModel{
+		ConfigContext:         types.StringNull(),
+		ConfigContextAuthInfo: types.StringNull(),
+		ConfigContextCluster:  types.StringNull(),
+		Host:                  types.StringNull(),
+		Insecure:              types.BoolNull(),
+		TLSServerName:         types.StringNull(),
+		ClusterCACertificate:  types.StringNull(),
+		ClientCertificate:     types.StringNull(),
+		ClientKey:             types.StringNull(),
+		Token:                 types.StringNull(),
+		Username:              types.StringNull(),
+		Password:              types.StringNull(),
+	}
+}
+
+func TestClientCache(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: one
+contexts:
+- name: one
+  context: {cluster: one, user: admin}
+- name: two
+  context: {cluster: two, user: admin}
+clusters:
+- name: one
+  cluster: {server: "https://one.example.com"}
+- name: two
+  cluster: {server: "https://two.example.com"}
+users:
+- name: admin
+  user: {token: secret}
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clients := newClientCache(KubernetesPatchProviderModel{ConfigPath: types.StringValue(kubeconfig)}, testRetryPolicy)
+	cluster := func(set func(*ClusterModel)) []ClusterModel {
+		c := testClusterModel()
+		set(&c)
+		return []ClusterModel{c}
+	}
+	client := func(cluster []ClusterModel) *KubernetesClient {
+		t.Helper()
+		c, diags := clients.client(cluster)
+		if diags.HasError() || c == nil {
+			t.Fatalf("unexpected diagnostics: %v", diags)
+		}
+		return c
+	}
+
+	provider := client(nil)
+	if client(nil) != provider {
+		t.Error("expected the provider client to be reused")
+	}
+	if client(cluster(func(c *ClusterModel) { c.ConfigContext = types.StringValue("one") })) != provider {
+		t.Error("expected a cluster block resolving to the provider configuration to reuse its client")
+	}
+
+	two := client(cluster(func(c *ClusterModel) { c.ConfigContext = types.StringValue("two") }))
+	if two == provider {
+		t.Error("expected another context to get its own client")
+	}
+	if client(cluster(func(c *ClusterModel) { c.Host = types.StringValue("https://two.example.com") })) != two {
+		t.Error("expected the same host and credentials to share a client")
+	}
+	if client(cluster(func(c *ClusterModel) {
+		c.ConfigContext = types.StringValue("two")
+		c.Token = types.StringValue("other")
+	})) == two {
+		t.Error("expected other credentials to get their own client")
+	}
+
+	if c, diags := clients.client(cluster(func(c *ClusterModel) { c.ConfigContext = types.StringValue("three") })); c != nil || len(diags) == 0 {
+		t.Error("expected an unknown context to be reported")
+	}
+}
+
+func TestClusterModelKnown(t *testing.T) {
+	c := testClusterModel()
+	if !c.known() {
+		t.Error("expected null settings to be known")
+	}
+	c.Token = basetypes.NewStringUnknown()
+	if c.known() {
+		t.Error("expected an unknown token to make the block unknown")
+	}
+}
+
+func TestPatchResourceCluster(t *testing.T) {
+	settings := testConfigMap("default", "settings", nil, map[string]any{"key": "original"})
+	local := newFakeAPIServer(t, settings)
+	remote := newFakeAPIServer(t, settings)
+	h := newResourceHarness(t, NewPatchResource(), local)
+
+	cluster := testClusterModel()
+	cluster.Host = types.StringValue(remote.URL)
+	config := &PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+		Cluster:    []ClusterModel{cluster},
+	}
+
+	h.apply(config)
+
+	value := func(server *fakeAPIServer) string {
+		v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key")
+		return v
+	}
+	if value(remote) != "patched" || value(local) != "original" {
+		t.Fatalf("expected only the object of the cluster block's host to be patched, got %q remotely and %q locally", value(remote), value(local))
+	}
+
+	h.refresh()
+	if h.planChanges(config) {
+		t.Error("expected no changes after a refresh")
+	}
+
+	h.destroy()
+	if value(remote) != "original" {
+		t.Errorf("expected the patch to be reverted, got %q", value(remote))
+	}
+}
+
+func TestPatchResourceClusterWithoutProviderCluster(t *testing.T) {
+	t.Setenv("KUBE_CONFIG_PATHS", "")
+	ctx := context.Background()
+
+	// Configure the provider with an empty provider block.
+	p := New("test")()
+	var schemaResp provider.SchemaResponse
+	p.Schema(ctx, provider.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, typ := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	var configureResp provider.ConfigureResponse
+	p.Configure(ctx, provider.ConfigureRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}}, &configureResp)
+	if configureResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected configure diagnostics: %v", configureResp.Diagnostics)
+	}
+	providerData, ok := configureResp.ResourceData.(*ProviderData)
+	if !ok || providerData.Client != nil {
+		t.Fatalf("expected provider data without a default client, got %#v", configureResp.ResourceData)
+	}
+
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	h := newResourceHarnessWithProviderData(t, NewPatchResource(), providerData)
+
+	cluster := testClusterModel()
+	cluster.Host = types.StringValue(server.URL)
+	cluster.Token = types.StringValue("secret")
+	config := &PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+		Cluster:    []ClusterModel{cluster},
+	}
+
+	h.apply(config)
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key"); v != "patched" {
+		t.Errorf("expected the object of the cluster block's host to be patched, got %q", v)
+	}
+
+	// Resources without a cluster block have no cluster to patch.
+	config.Cluster = nil
+	if _, diags := newResourceHarnessWithProviderData(t, NewPatchResource(), providerData).tryApply(config); !diags.HasError() {
+		t.Error("expected a resource without a cluster block to report the missing provider cluster")
+	}
+}
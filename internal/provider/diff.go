@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// diffJSON returns the RFC 6902 operations that turn from into to. Both are
+// generic JSON values as produced by encoding/json.
+func diffJSON(from, to any) []jsonPatchOperation {
+	return appendDiff(nil, nil, from, to)
+}
+
+func appendDiff(ops []jsonPatchOperation, path []string, from, to any) []jsonPatchOperation {
+	switch f := from.(type) {
+	case map[string]any:
+		t, ok := to.(map[string]any)
+		if !ok {
+			break
+		}
+
+		keys := make([]string, 0, len(f)+len(t))
+		for key := range f {
+			keys = append(keys, key)
+		}
+		for key := range t {
+			if _, ok := f[key]; !ok {
+				keys = append(keys, key)
+			}
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fromValue, inFrom := f[key]
+			toValue, inTo := t[key]
+			switch {
+			case !inTo:
+				ops = append(ops, jsonPatchOperation{Op: "remove", Path: formatPointer(appendPath(path, key))})
+			case !inFrom:
+				ops = append(ops, jsonPatchOperation{Op: "add", Path: formatPointer(appendPath(path, key)), Value: toValue})
+			default:
+				ops = appendDiff(ops, appendPath(path, key), fromValue, toValue)
+			}
+		}
+		return ops
+	case []any:
+		t, ok := to.([]any)
+		if !ok {
+			break
+		}
+
+		common := min(len(f), len(t))
+		for i := 0; i < common; i++ {
+			ops = appendDiff(ops, appendPath(path, strconv.Itoa(i)), f[i], t[i])
+		}
+		for i := common; i < len(t); i++ {
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: formatPointer(appendPath(path, strconv.Itoa(i))), Value: t[i]})
+		}
+		// Remove from the end so earlier indices stay valid.
+		for i := len(f) - 1; i >= common; i-- {
+			ops = append(ops, jsonPatchOperation{Op: "remove", Path: formatPointer(appendPath(path, strconv.Itoa(i)))})
+		}
+		return ops
+	}
+
+	if !reflect.DeepEqual(from, to) {
+		ops = append(ops, jsonPatchOperation{Op: "replace", Path: formatPointer(path), Value: to})
+	}
+	return ops
+}
+
+// diffMerge returns the RFC 7386 merge patch turning the object from into to.
+// Numbers are compared as decoded, so that decoding with decodeJSON keeps
+// large integers apart.
+func diffMerge(from, to map[string]any) map[string]any {
+	patch := map[string]any{}
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			patch[key] = nil
+		}
+	}
+	for key, toValue := range to {
+		fromValue, ok := from[key]
+		if !ok {
+			patch[key] = toValue
+			continue
+		}
+		fromObject, fromIsObject := fromValue.(map[string]any)
+		toObject, toIsObject := toValue.(map[string]any)
+		if fromIsObject && toIsObject {
+			if changes := diffMerge(fromObject, toObject); len(changes) > 0 {
+				patch[key] = changes
+			}
+			continue
+		}
+		if !reflect.DeepEqual(fromValue, toValue) {
+			patch[key] = toValue
+		}
+	}
+	return patch
+}
+
+// describeChanges renders the difference between from and to as one line per
+// changed field, in the style of a Terraform plan.
+func describeChanges(from, to any) []string {
+	var changes []string
+	for _, op := range diffJSON(from, to) {
+		path, _ := parsePointer(op.Path)
+		switch op.Op {
+		case "add":
+			changes = append(changes, fmt.Sprintf("+ %s: %s", op.Path, compactJSON(op.Value)))
+		case "remove":
+			old, _ := getPointer(from, path)
+			changes = append(changes, fmt.Sprintf("- %s: %s", op.Path, compactJSON(old)))
+		case "replace":
+			old, _ := getPointer(from, path)
+			changes = append(changes, fmt.Sprintf("~ %s: %s -> %s", op.Path, compactJSON(old), compactJSON(op.Value)))
+		}
+	}
+	return changes
+}
+
+func compactJSON(value any) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
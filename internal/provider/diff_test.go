@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+)
+
+func TestDiffJSON(t *testing.T) {
+	testCases := map[string]struct {
+		from string
+		to   string
+	}{
+		"equal": {
+			from: `{"a": 1}`,
+			to:   `{"a": 1}`,
+		},
+		"object fields": {
+			from: `{"a": 1, "b": {"c": "d", "e": "f"}, "g": true}`,
+			to:   `{"a": 2, "b": {"c": "d", "x": null}, "h": [1]}`,
+		},
+		"array grows": {
+			from: `{"args": ["--a"]}`,
+			to:   `{"args": ["--b", "--c", "--d"]}`,
+		},
+		"array shrinks": {
+			from: `{"args": ["--a", "--b", "--c"]}`,
+			to:   `{"args": ["--a"]}`,
+		},
+		"type change": {
+			from: `{"a": {"b": 1}}`,
+			to:   `{"a": [1]}`,
+		},
+		"escaped keys": {
+			from: `{"labels": {"example.com/team": "a"}}`,
+			to:   `{"labels": {"example.com/team": "b", "a~b": "c"}}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var from, to any
+			if err := json.Unmarshal([]byte(tc.from), &from); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal([]byte(tc.to), &to); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := json.Marshal(diffJSON(from, to))
+			if err != nil {
+				t.Fatal(err)
+			}
+			patch, err := jsonpatch.DecodePatch(b)
+			if err != nil {
+				t.Fatalf("invalid patch %s: %s", b, err)
+			}
+			result, err := patch.Apply([]byte(tc.from))
+			if err != nil {
+				t.Fatalf("could not apply patch %s: %s", b, err)
+			}
+
+			var actual any
+			if err := json.Unmarshal(result, &actual); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(to, actual) {
+				t.Fatalf("expected %s, got %s (patch %s)", tc.to, result, b)
+			}
+		})
+	}
+}
+
+func TestDescribeChanges(t *testing.T) {
+	var from, to any
+	if err := json.Unmarshal([]byte(`{"spec": {"replicas": 1, "paused": true}}`), &from); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal([]byte(`{"spec": {"replicas": 3, "selector": {"app": "x"}}}`), &to); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{
+		"- /spec/paused: true",
+		"~ /spec/replicas: 1 -> 3",
+		`+ /spec/selector: {"app":"x"}`,
+	}
+	if actual := describeChanges(from, to); !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %q, got %q", expected, actual)
+	}
+}
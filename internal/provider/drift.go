@@ -0,0 +1,240 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// jsonPatchOperation is a single RFC 6902 operation.
+type jsonPatchOperation struct {
+	Op    string `json:"op"`
+	Path  string `json:"path"`
+	From  string `json:"from,omitempty"`
+	Value any    `json:"value,omitempty"`
+}
+
+// MarshalJSON includes value for the operations that require it, even when
+// it is null.
+func (o jsonPatchOperation) MarshalJSON() ([]byte, error) {
+	type operation jsonPatchOperation
+	switch o.Op {
+	case "add", "replace", "test":
+		return json.Marshal(struct {
+			operation
+			Value any `json:"value"`
+		}{operation(o), o.Value})
+	default:
+		return json.Marshal(operation(o))
+	}
+}
+
+// patchInEffect reports whether patch is still reflected in the live object.
+// When it is not, the returned reason describes the first difference found.
+// Annotations and labels ignored by the filter are not compared.
+func patchInEffect(liveJSON []byte, patchType string, patch []byte, gvk schema.GroupVersionKind, ignore MetadataFilter) (bool, string, error) {
+	var live map[string]any
+	if err := json.Unmarshal(liveJSON, &live); err != nil {
+		return false, "", err
+	}
+
+	switch patchType {
+	case "json":
+		return jsonPatchInEffect(live, patch, ignore)
+	case "apply":
+		return applyInEffect(live, patch, ignore)
+	case "strategic":
+		if dataStruct, ok := strategicDataStruct(gvk); ok {
+			return strategicPatchInEffect(live, patch, dataStruct, ignore)
+		}
+		// Strategic merge patches are only supported for built-in types, so
+		// the API server would have rejected this patch anyway; fall back
+		// to merge semantics.
+		return mergePatchInEffect(live, patch, ignore)
+	default:
+		return mergePatchInEffect(live, patch, ignore)
+	}
+}
+
+// jsonPatchInEffect evaluates each operation against the live object.
+// Operations whose effect can no longer be observed, such as removing an
+// array element by index, are assumed to still be in effect.
+func jsonPatchInEffect(live map[string]any, patch []byte, ignore MetadataFilter) (bool, string, error) {
+	var ops []jsonPatchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return false, "", fmt.Errorf("could not decode JSON patch: %w", err)
+	}
+
+	for _, op := range ops {
+		path, err := parsePointer(op.Path)
+		if err != nil {
+			return false, "", err
+		}
+		if ignore.ignoresPath(path) {
+			continue
+		}
+		// Values are compared without the ignored annotations and labels
+		// they contain.
+		equal := func(v any) bool {
+			return reflect.DeepEqual(ignore.stripAt(path, v), ignore.stripAt(path, op.Value))
+		}
+
+		switch op.Op {
+		case "add":
+			if len(path) > 0 {
+				if parent, ok := getPointer(live, path[:len(path)-1]); ok {
+					if elements, ok := parent.([]any); ok && path[len(path)-1] == "-" {
+						if !containsValue(elements, op.Value) {
+							return false, fmt.Sprintf("%s does not contain the added value", formatPointer(path[:len(path)-1])), nil
+						}
+						continue
+					}
+				}
+			}
+			if v, ok := getPointer(live, path); !ok || !equal(v) {
+				return false, fmt.Sprintf("%s does not have the added value", op.Path), nil
+			}
+		case "replace":
+			if v, ok := getPointer(live, path); !ok || !equal(v) {
+				return false, fmt.Sprintf("%s does not have the replaced value", op.Path), nil
+			}
+		case "remove":
+			if parentIsArray(live, path) {
+				continue
+			}
+			if _, ok := getPointer(live, path); ok {
+				return false, fmt.Sprintf("%s has not been removed", op.Path), nil
+			}
+		case "move":
+			from, err := parsePointer(op.From)
+			if err != nil {
+				return false, "", err
+			}
+			if ignore.ignoresPath(from) {
+				continue
+			}
+			if parentIsArray(live, from) {
+				continue
+			}
+			if _, ok := getPointer(live, from); ok {
+				return false, fmt.Sprintf("%s has not been moved to %s", op.From, op.Path), nil
+			}
+		case "copy":
+			from, err := parsePointer(op.From)
+			if err != nil {
+				return false, "", err
+			}
+			if ignore.ignoresPath(from) {
+				continue
+			}
+			src, _ := getPointer(live, from)
+			if v, ok := getPointer(live, path); !ok || !reflect.DeepEqual(v, src) {
+				return false, fmt.Sprintf("%s is not a copy of %s", op.Path, op.From), nil
+			}
+		}
+	}
+
+	return true, "", nil
+}
+
+// mergePatchInEffect checks that applying the patch to the live object is a
+// no-op, i.e. that the patch is a subset of the live object.
+func mergePatchInEffect(live map[string]any, patch []byte, ignore MetadataFilter) (bool, string, error) {
+	original, err := json.Marshal(live)
+	if err != nil {
+		return false, "", err
+	}
+	patched, err := jsonpatch.MergePatch(original, patch)
+	if err != nil {
+		return false, "", fmt.Errorf("could not apply merge patch: %w", err)
+	}
+	return compareDocuments(live, patched, ignore)
+}
+
+// strategicPatchInEffect is the strategic merge equivalent of
+// mergePatchInEffect, honoring the patch strategies of dataStruct.
+func strategicPatchInEffect(live map[string]any, patch []byte, dataStruct runtime.Object, ignore MetadataFilter) (bool, string, error) {
+	original, err := json.Marshal(live)
+	if err != nil {
+		return false, "", err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, patch, dataStruct)
+	if err != nil {
+		return false, "", fmt.Errorf("could not apply strategic merge patch: %w", err)
+	}
+	return compareDocuments(live, patched, ignore)
+}
+
+func compareDocuments(live map[string]any, patched []byte, ignore MetadataFilter) (bool, string, error) {
+	var result map[string]any
+	if err := json.Unmarshal(patched, &result); err != nil {
+		return false, "", err
+	}
+	if reflect.DeepEqual(ignore.strip(live), ignore.strip(result)) {
+		return true, "", nil
+	}
+	return false, "the live object differs from the patched object", nil
+}
+
+// strategicDataStruct returns the typed object carrying the patch strategies
+// for gvk, if it is a built-in type.
+func strategicDataStruct(gvk schema.GroupVersionKind) (runtime.Object, bool) {
+	obj, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+func containsValue(elements []any, value any) bool {
+	for _, e := range elements {
+		if reflect.DeepEqual(e, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func parentIsArray(doc any, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+	parent, ok := getPointer(doc, path[:len(path)-1])
+	if !ok {
+		return false
+	}
+	_, ok = parent.([]any)
+	return ok
+}
+
+// inEffectPlanModifier plans in_effect as true, since applying the resource
+// always leaves the patch in effect. A prior state of false, set by Read when
+// drift is detected, therefore shows up as a change and re-applies the patch.
+type inEffectPlanModifier struct{}
+
+func (m inEffectPlanModifier) Description(ctx context.Context) string {
+	return "Re-applies the patch when it is no longer in effect."
+}
+
+func (m inEffectPlanModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m inEffectPlanModifier) PlanModifyBool(ctx context.Context, req planmodifier.BoolRequest, resp *planmodifier.BoolResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	resp.PlanValue = types.BoolValue(true)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"regexp"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPatchInEffect(t *testing.T) {
+	deployment := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	live := `{
+  "metadata": {"name": "test", "labels": {"app": "test"}},
+  "spec": {
+    "replicas": 2,
+    "template": {"spec": {"containers": [
+      {"name": "app", "image": "app:1", "args": ["--a", "--b"]},
+      {"name": "sidecar", "image": "sidecar:1"}
+    ]}}
+  }
+}`
+
+	testCases := map[string]struct {
+		patchType string
+		patch     string
+		gvk       schema.GroupVersionKind
+		expected  bool
+	}{
+		"json replace in effect": {
+			patchType: "json",
+			patch:     `[{"op": "replace", "path": "/spec/replicas", "value": 2}]`,
+			expected:  true,
+		},
+		"json replace drifted": {
+			patchType: "json",
+			patch:     `[{"op": "replace", "path": "/spec/replicas", "value": 3}]`,
+		},
+		"json add appended value present": {
+			patchType: "json",
+			patch:     `[{"op": "add", "path": "/spec/template/spec/containers/0/args/-", "value": "--a"}]`,
+			expected:  true,
+		},
+		"json add appended value missing": {
+			patchType: "json",
+			patch:     `[{"op": "add", "path": "/spec/template/spec/containers/0/args/-", "value": "--c"}]`,
+		},
+		"json remove in effect": {
+			patchType: "json",
+			patch:     `[{"op": "remove", "path": "/metadata/annotations"}]`,
+			expected:  true,
+		},
+		"json remove drifted": {
+			patchType: "json",
+			patch:     `[{"op": "remove", "path": "/metadata/labels/app"}]`,
+		},
+		"json escaped path": {
+			patchType: "json",
+			patch:     `[{"op": "add", "path": "/metadata/labels/example.com~1team", "value": "obs"}]`,
+		},
+		"merge subset": {
+			patchType: "merge",
+			patch:     `{"metadata": {"labels": {"app": "test"}}, "spec": {"replicas": 2}}`,
+			expected:  true,
+		},
+		"merge deleted key in effect": {
+			patchType: "merge",
+			patch:     `{"metadata": {"labels": {"team": null}}}`,
+			expected:  true,
+		},
+		"merge drifted": {
+			patchType: "merge",
+			patch:     `{"metadata": {"labels": {"team": "obs"}}}`,
+		},
+		"strategic list merge in effect": {
+			patchType: "strategic",
+			patch:     `{"spec": {"template": {"spec": {"containers": [{"name": "sidecar", "image": "sidecar:1"}]}}}}`,
+			gvk:       deployment,
+			expected:  true,
+		},
+		"strategic list merge drifted": {
+			patchType: "strategic",
+			patch:     `{"spec": {"template": {"spec": {"containers": [{"name": "sidecar", "image": "sidecar:2"}]}}}}`,
+			gvk:       deployment,
+		},
+		"strategic delete directive in effect": {
+			patchType: "strategic",
+			patch:     `{"spec": {"template": {"spec": {"containers": [{"name": "debug", "$patch": "delete"}]}}}}`,
+			gvk:       deployment,
+			expected:  true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			inEffect, reason, err := patchInEffect([]byte(live), tc.patchType, []byte(tc.patch), tc.gvk, MetadataFilter{})
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if inEffect != tc.expected {
+				t.Fatalf("expected in effect to be %t, got %t (%s)", tc.expected, inEffect, reason)
+			}
+		})
+	}
+}
+
+func TestPatchInEffectIgnoredMetadata(t *testing.T) {
+	ignore := MetadataFilter{
+		annotations: []*regexp.Regexp{regexp.MustCompile(`^example\.com/`)},
+		labels:      []*regexp.Regexp{regexp.MustCompile(`^team$`)},
+	}
+	live := `{
+  "metadata": {
+    "name": "test",
+    "annotations": {"example.com/revision": "2", "owner": "platform"},
+    "labels": {"app": "test", "team": "obs"}
+  },
+  "spec": {"replicas": 2}
+}`
+
+	testCases := map[string]struct {
+		patchType string
+		patch     string
+		expected  bool
+	}{
+		"merge ignored annotation changed": {
+			patchType: "merge",
+			patch:     `{"metadata": {"annotations": {"example.com/revision": "1", "owner": "platform"}}}`,
+			expected:  true,
+		},
+		"merge other annotation changed": {
+			patchType: "merge",
+			patch:     `{"metadata": {"annotations": {"example.com/revision": "1", "owner": "apps"}}}`,
+		},
+		"merge ignored label removed": {
+			patchType: "merge",
+			patch:     `{"metadata": {"labels": {"team": null}}}`,
+			expected:  true,
+		},
+		"json ignored annotation": {
+			patchType: "json",
+			patch:     `[{"op": "replace", "path": "/metadata/annotations/example.com~1revision", "value": "1"}]`,
+			expected:  true,
+		},
+		"json annotations with ignored keys": {
+			patchType: "json",
+			patch:     `[{"op": "replace", "path": "/metadata/annotations", "value": {"example.com/revision": "1", "owner": "platform"}}]`,
+			expected:  true,
+		},
+		"json labels with ignored keys": {
+			patchType: "json",
+			patch:     `[{"op": "add", "path": "/metadata/labels", "value": {"app": "test", "team": "apps"}}]`,
+			expected:  true,
+		},
+		"json labels removed": {
+			patchType: "json",
+			patch:     `[{"op": "replace", "path": "/metadata/labels", "value": {"team": "apps"}}]`,
+		},
+		"apply ignored label": {
+			patchType: "apply",
+			patch:     `{"metadata": {"labels": {"team": "apps", "app": "test"}}}`,
+			expected:  true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			inEffect, reason, err := patchInEffect([]byte(live), tc.patchType, []byte(tc.patch), schema.GroupVersionKind{}, ignore)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if inEffect != tc.expected {
+				t.Fatalf("expected in effect to be %t, got %t (%s)", tc.expected, inEffect, reason)
+			}
+		})
+	}
+}
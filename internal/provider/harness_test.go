@@ -0,0 +1,385 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+)
+
+// resourceHarness drives a resource through validation, plan, apply, refresh
+// and destroy the way Terraform does, against a fakeAPIServer and without the
+// Terraform CLI. Configurations and states are the resource's models.
+//
+// Planning follows the framework: defaults and prior state fill in computed
+// attributes the configuration leaves null, which become unknown when the
+// plan changes anything, before string and bool plan modifiers and the
+// resource's ModifyPlan run. Schema validators are not run.
+type resourceHarness struct {
+	t        *testing.T
+	resource resource.Resource
+	schema   schema.Schema
+	state    tfsdk.State
+}
+
+func newResourceHarness(t *testing.T, r resource.Resource, server *fakeAPIServer) *resourceHarness {
+	return newResourceHarnessWithProviderData(t, r, server.providerData(t))
+}
+
+// newResourceHarnessWithProviderData is newResourceHarness for resources
+// configured with providerData, for example from the provider's Configure.
+func newResourceHarnessWithProviderData(t *testing.T, r resource.Resource, providerData any) *resourceHarness {
+	ctx := context.Background()
+
+	if c, ok := r.(resource.ResourceWithConfigure); ok {
+		var resp resource.ConfigureResponse
+		c.Configure(ctx, resource.ConfigureRequest{ProviderData: providerData}, &resp)
+		if resp.Diagnostics.HasError() {
+			t.Fatalf("unexpected configure diagnostics: %v", resp.Diagnostics)
+		}
+	}
+
+	var resp resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected schema diagnostics: %v", resp.Diagnostics)
+	}
+
+	return &resourceHarness{
+		t:        t,
+		resource: r,
+		schema:   resp.Schema,
+		state:    tfsdk.State{Schema: resp.Schema, Raw: tftypes.NewValue(resp.Schema.Type().TerraformType(ctx), nil)},
+	}
+}
+
+// apply validates, plans and applies config, failing the test on error
+// diagnostics. It reports whether the plan had changes.
+func (h *resourceHarness) apply(config any) bool {
+	h.t.Helper()
+
+	changed, diags := h.tryApply(config)
+	if diags.HasError() {
+		h.t.Fatalf("unexpected apply diagnostics: %v", diags)
+	}
+	return changed
+}
+
+// tryApply is apply returning the diagnostics instead of failing the test.
+func (h *resourceHarness) tryApply(config any) (bool, diag.Diagnostics) {
+	ctx := context.Background()
+
+	plan, diags := h.plan(config)
+	if diags.HasError() || plan.Raw.Equal(h.state.Raw) {
+		return false, diags
+	}
+
+	if h.state.Raw.IsNull() {
+		req := resource.CreateRequest{Plan: plan}
+		resp := resource.CreateResponse{State: tfsdk.State{Schema: h.schema, Raw: plan.Raw.Copy()}}
+		h.resource.Create(ctx, req, &resp)
+		diags.Append(resp.Diagnostics...)
+		if !resp.Diagnostics.HasError() || !resp.State.Raw.Equal(plan.Raw) {
+			h.state = resp.State
+		}
+		return true, diags
+	}
+
+	req := resource.UpdateRequest{Plan: plan, State: h.state}
+	resp := resource.UpdateResponse{State: tfsdk.State{Schema: h.schema, Raw: plan.Raw.Copy()}}
+	h.resource.Update(ctx, req, &resp)
+	diags.Append(resp.Diagnostics...)
+	if !resp.Diagnostics.HasError() || !resp.State.Raw.Equal(plan.Raw) {
+		h.state = resp.State
+	}
+	return true, diags
+}
+
+// plan validates and plans config against the current state.
+func (h *resourceHarness) plan(config any) (tfsdk.Plan, diag.Diagnostics) {
+	ctx := context.Background()
+
+	cfg, diags := h.config(config)
+	if diags.HasError() {
+		return tfsdk.Plan{}, diags
+	}
+
+	if v, ok := h.resource.(resource.ResourceWithValidateConfig); ok {
+		var resp resource.ValidateConfigResponse
+		v.ValidateConfig(ctx, resource.ValidateConfigRequest{Config: cfg}, &resp)
+		diags.Append(resp.Diagnostics...)
+		if diags.HasError() {
+			return tfsdk.Plan{}, diags
+		}
+	}
+
+	prior := h.state.Raw
+	computed := func(name string) (schema.Attribute, bool) {
+		attribute, ok := h.schema.Attributes[name]
+		return attribute, ok && attribute.IsComputed()
+	}
+
+	// Computed attributes left null by the configuration get their default,
+	// or keep their prior value.
+	proposed, err := h.transformAttributes(cfg.Raw, func(name string, v tftypes.Value) (tftypes.Value, error) {
+		attribute, ok := computed(name)
+		if !ok || !v.IsNull() {
+			return v, nil
+		}
+		if def, ok := defaultValue(ctx, attribute); ok {
+			return def.ToTerraformValue(ctx)
+		}
+		if !prior.IsNull() {
+			return attributeValue(prior, name)
+		}
+		return tftypes.NewValue(v.Type(), tftypes.UnknownValue), nil
+	})
+	if err != nil {
+		diags.AddError("Harness Error", err.Error())
+		return tfsdk.Plan{}, diags
+	}
+
+	if !prior.IsNull() && !proposed.Equal(prior) {
+		proposed, err = h.transformAttributes(proposed, func(name string, v tftypes.Value) (tftypes.Value, error) {
+			attribute, ok := computed(name)
+			configValue, _ := attributeValue(cfg.Raw, name)
+			if _, hasDefault := defaultValue(ctx, attribute); !ok || hasDefault || !configValue.IsNull() {
+				return v, nil
+			}
+			return tftypes.NewValue(v.Type(), tftypes.UnknownValue), nil
+		})
+		if err != nil {
+			diags.AddError("Harness Error", err.Error())
+			return tfsdk.Plan{}, diags
+		}
+	}
+
+	plan := tfsdk.Plan{Schema: h.schema, Raw: proposed}
+	for name, attribute := range h.schema.Attributes {
+		diags.Append(h.modifyAttribute(ctx, cfg, &plan, name, attribute)...)
+	}
+
+	if m, ok := h.resource.(resource.ResourceWithModifyPlan); ok {
+		req := resource.ModifyPlanRequest{Config: cfg, Plan: plan, State: h.state}
+		resp := resource.ModifyPlanResponse{Plan: plan}
+		m.ModifyPlan(ctx, req, &resp)
+		diags.Append(resp.Diagnostics...)
+		plan = resp.Plan
+	}
+
+	return plan, diags
+}
+
+// planChanges reports whether planning config against the current state
+// shows changes, failing the test on error diagnostics.
+func (h *resourceHarness) planChanges(config any) bool {
+	h.t.Helper()
+
+	plan, diags := h.plan(config)
+	if diags.HasError() {
+		h.t.Fatalf("unexpected plan diagnostics: %v", diags)
+	}
+	return !plan.Raw.Equal(h.state.Raw)
+}
+
+// refresh reads the resource into the current state, failing the test on
+// error diagnostics.
+func (h *resourceHarness) refresh() {
+	h.t.Helper()
+
+	req := resource.ReadRequest{State: h.state}
+	resp := resource.ReadResponse{State: h.state}
+	h.resource.Read(context.Background(), req, &resp)
+	if resp.Diagnostics.HasError() {
+		h.t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+	h.state = resp.State
+}
+
+// destroy deletes the resource, failing the test on error diagnostics.
+func (h *resourceHarness) destroy() {
+	h.t.Helper()
+
+	req := resource.DeleteRequest{State: h.state}
+	resp := resource.DeleteResponse{State: h.state}
+	h.resource.Delete(context.Background(), req, &resp)
+	if resp.Diagnostics.HasError() {
+		h.t.Fatalf("unexpected delete diagnostics: %v", resp.Diagnostics)
+	}
+	h.state.RemoveResource(context.Background())
+}
+
+// get reads the current state into target, a pointer to the resource's model.
+func (h *resourceHarness) get(target any) {
+	h.t.Helper()
+
+	if diags := h.state.Get(context.Background(), target); diags.HasError() {
+		h.t.Fatalf("unexpected state diagnostics: %v", diags)
+	}
+}
+
+// config converts a model into a configuration. Zero values of the model's
+// fields are null, like omitted arguments.
+func (h *resourceHarness) config(model any) (tfsdk.Config, diag.Diagnostics) {
+	ctx := context.Background()
+
+	// Zero values of collection and object types lack their element types,
+	// which the schema provides.
+	v := reflect.New(reflect.TypeOf(model).Elem()).Elem()
+	v.Set(reflect.ValueOf(model).Elem())
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Tag.Get("tfsdk")
+		field := v.Field(i)
+		if name == "" || !field.IsZero() || !field.Type().Implements(reflect.TypeOf((*attr.Value)(nil)).Elem()) {
+			continue
+		}
+
+		typ, diags := h.schema.TypeAtPath(ctx, path.Root(name))
+		if diags.HasError() {
+			return tfsdk.Config{}, diags
+		}
+		null, err := typ.ValueFromTerraform(ctx, tftypes.NewValue(typ.TerraformType(ctx), nil))
+		if err != nil {
+			diags.AddError("Harness Error", err.Error())
+			return tfsdk.Config{}, diags
+		}
+		if reflect.TypeOf(null).AssignableTo(field.Type()) {
+			field.Set(reflect.ValueOf(null))
+		}
+	}
+
+	state := tfsdk.State{Schema: h.schema}
+	diags := state.Set(ctx, v.Addr().Interface())
+	return tfsdk.Config{Schema: h.schema, Raw: state.Raw}, diags
+}
+
+// modifyAttribute runs the plan modifiers of string and bool attributes.
+func (h *resourceHarness) modifyAttribute(ctx context.Context, cfg tfsdk.Config, plan *tfsdk.Plan, name string, attribute schema.Attribute) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	value := func(raw tftypes.Value) attributeAs {
+		v, _ := attributeValue(raw, name)
+		converted, _ := attribute.GetType().ValueFromTerraform(ctx, v)
+		return attributeAs{converted}
+	}
+
+	switch a := attribute.(type) {
+	case schema.StringAttribute:
+		for _, m := range a.PlanModifiers {
+			req := planmodifier.StringRequest{
+				Path:        path.Root(name),
+				Config:      cfg,
+				ConfigValue: value(cfg.Raw).string(ctx),
+				Plan:        *plan,
+				PlanValue:   value(plan.Raw).string(ctx),
+				State:       h.state,
+				StateValue:  value(h.state.Raw).string(ctx),
+			}
+			resp := planmodifier.StringResponse{PlanValue: req.PlanValue}
+			m.PlanModifyString(ctx, req, &resp)
+			diags.Append(resp.Diagnostics...)
+			diags.Append(plan.SetAttribute(ctx, path.Root(name), resp.PlanValue)...)
+		}
+	case schema.BoolAttribute:
+		for _, m := range a.PlanModifiers {
+			req := planmodifier.BoolRequest{
+				Path:        path.Root(name),
+				Config:      cfg,
+				ConfigValue: value(cfg.Raw).bool(ctx),
+				Plan:        *plan,
+				PlanValue:   value(plan.Raw).bool(ctx),
+				State:       h.state,
+				StateValue:  value(h.state.Raw).bool(ctx),
+			}
+			resp := planmodifier.BoolResponse{PlanValue: req.PlanValue}
+			m.PlanModifyBool(ctx, req, &resp)
+			diags.Append(resp.Diagnostics...)
+			diags.Append(plan.SetAttribute(ctx, path.Root(name), resp.PlanValue)...)
+		}
+	}
+	return diags
+}
+
+// attributeAs converts attribute values of custom types to their base type.
+type attributeAs struct {
+	value any
+}
+
+func (a attributeAs) string(ctx context.Context) types.String {
+	if v, ok := a.value.(basetypes.StringValuable); ok {
+		s, _ := v.ToStringValue(ctx)
+		return s
+	}
+	return types.StringNull()
+}
+
+func (a attributeAs) bool(ctx context.Context) types.Bool {
+	if v, ok := a.value.(basetypes.BoolValuable); ok {
+		b, _ := v.ToBoolValue(ctx)
+		return b
+	}
+	return types.BoolNull()
+}
+
+// transformAttributes replaces the top-level attributes of raw by the result
+// of fn.
+func (h *resourceHarness) transformAttributes(raw tftypes.Value, fn func(name string, v tftypes.Value) (tftypes.Value, error)) (tftypes.Value, error) {
+	return tftypes.Transform(raw, func(p *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		steps := p.Steps()
+		if len(steps) != 1 {
+			return v, nil
+		}
+		name, ok := steps[0].(tftypes.AttributeName)
+		if !ok {
+			return v, nil
+		}
+		if _, ok := h.schema.Attributes[string(name)]; !ok {
+			return v, nil
+		}
+		return fn(string(name), v)
+	})
+}
+
+// attributeValue returns the top-level attribute name of raw.
+func attributeValue(raw tftypes.Value, name string) (tftypes.Value, error) {
+	v, _, err := tftypes.WalkAttributePath(raw, tftypes.NewAttributePath().WithAttributeName(name))
+	if err != nil {
+		return tftypes.Value{}, err
+	}
+	return v.(tftypes.Value), nil
+}
+
+// defaultValue returns the default of a string or bool attribute, if any.
+func defaultValue(ctx context.Context, attribute schema.Attribute) (interface {
+	ToTerraformValue(context.Context) (tftypes.Value, error)
+}, bool) {
+	switch a := attribute.(type) {
+	case schema.StringAttribute:
+		if a.Default != nil {
+			var resp defaults.StringResponse
+			a.Default.DefaultString(ctx, defaults.StringRequest{}, &resp)
+			return resp.PlanValue, true
+		}
+	case schema.BoolAttribute:
+		if a.Default != nil {
+			var resp defaults.BoolResponse
+			a.Default.DefaultBool(ctx, defaults.BoolRequest{}, &resp)
+			return resp.PlanValue, true
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,44 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// patchIDFormat describes the identifier of kubepatch_patch resources.
+const patchIDFormat = "<apiVersion>/<resource>/<namespace>/<name>"
+
+// patchID returns the identifier of the patch of the named object, in the
+// form <apiVersion>/<resource>/<namespace>/<name>. The namespace is empty for
+// cluster-scoped resources.
+func patchID(mapping *meta.RESTMapping, namespace, name string) string {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		namespace = ""
+	}
+	return strings.Join([]string{mapping.GroupVersionKind.GroupVersion().String(), mapping.Resource.Resource, namespace, name}, "/")
+}
+
+// parsePatchID splits an identifier produced by patchID into its parts. The
+// API version is the only part that may contain a slash, so the identifier is
+// split from the right.
+func parsePatchID(id string) (apiVersion, resource, namespace, name string, err error) {
+	parts := strings.Split(id, "/")
+	if len(parts) < 4 || len(parts) > 5 {
+		return "", "", "", "", fmt.Errorf("expected an identifier of the form %s, got %q", patchIDFormat, id)
+	}
+
+	n := len(parts)
+	apiVersion = strings.Join(parts[:n-3], "/")
+	resource, namespace, name = parts[n-3], parts[n-2], parts[n-1]
+
+	if strings.HasPrefix(apiVersion, "/") || strings.HasSuffix(apiVersion, "/") || apiVersion == "" || resource == "" || name == "" {
+		return "", "", "", "", fmt.Errorf("expected an identifier of the form %s, got %q", patchIDFormat, id)
+	}
+
+	return apiVersion, resource, namespace, name, nil
+}
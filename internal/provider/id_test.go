@@ -0,0 +1,87 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestPatchID(t *testing.T) {
+	testCases := map[string]struct {
+		mapping    *meta.RESTMapping
+		namespace  string
+		name       string
+		expectedID string
+	}{
+		"namespaced": {
+			mapping: &meta.RESTMapping{
+				Resource:         schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+				GroupVersionKind: schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"},
+				Scope:            meta.RESTScopeNamespace,
+			},
+			namespace:  "default",
+			name:       "test",
+			expectedID: "apps/v1/deployments/default/test",
+		},
+		"core group": {
+			mapping: &meta.RESTMapping{
+				Resource:         schema.GroupVersionResource{Version: "v1", Resource: "configmaps"},
+				GroupVersionKind: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"},
+				Scope:            meta.RESTScopeNamespace,
+			},
+			namespace:  "kube-system",
+			name:       "coredns",
+			expectedID: "v1/configmaps/kube-system/coredns",
+		},
+		"cluster-scoped": {
+			mapping: &meta.RESTMapping{
+				Resource:         schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+				GroupVersionKind: schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"},
+				Scope:            meta.RESTScopeRoot,
+			},
+			namespace:  "ignored",
+			name:       "view",
+			expectedID: "rbac.authorization.k8s.io/v1/clusterroles//view",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			id := patchID(tc.mapping, tc.namespace, tc.name)
+			if id != tc.expectedID {
+				t.Fatalf("expected %q, got %q", tc.expectedID, id)
+			}
+
+			apiVersion, resource, namespace, name, err := parsePatchID(id)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if apiVersion != tc.mapping.GroupVersionKind.GroupVersion().String() || resource != tc.mapping.Resource.Resource || name != tc.name {
+				t.Fatalf("unexpected parts %q %q %q of %q", apiVersion, resource, name, id)
+			}
+			if tc.mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != tc.namespace {
+				t.Fatalf("expected namespace %q, got %q", tc.namespace, namespace)
+			}
+		})
+	}
+}
+
+func TestParsePatchIDInvalid(t *testing.T) {
+	for _, id := range []string{
+		"",
+		"example-id",
+		"deployments/default/test",
+		"apps/v1/deployments/default/",
+		"apps/v1//default/test",
+		"/v1/deployments/default/test",
+		"a/b/c/deployments/default/test",
+	} {
+		if _, _, _, _, err := parsePatchID(id); err == nil {
+			t.Errorf("expected an error for %q", id)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// MetadataFilter hides the annotations and labels matched by the provider's
+// ignore_annotations and ignore_labels, so that changes to them made by other
+// systems are neither reported as drift nor shown in computed objects.
+type MetadataFilter struct {
+	annotations []*regexp.Regexp
+	labels      []*regexp.Regexp
+}
+
+// NewMetadataFilter compiles the regular expressions of the ignore_annotations
+// and ignore_labels provider attributes.
+func NewMetadataFilter(ctx context.Context, annotations, labels types.List) (MetadataFilter, diag.Diagnostics) {
+	var f MetadataFilter
+	var diags diag.Diagnostics
+
+	f.annotations, diags = compileRegexps(ctx, "ignore_annotations", annotations)
+	labelRegexps, labelDiags := compileRegexps(ctx, "ignore_labels", labels)
+	diags.Append(labelDiags...)
+	f.labels = labelRegexps
+
+	return f, diags
+}
+
+func compileRegexps(ctx context.Context, attribute string, list types.List) ([]*regexp.Regexp, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if list.IsNull() || list.IsUnknown() {
+		return nil, diags
+	}
+
+	var expressions []string
+	diags.Append(list.ElementsAs(ctx, &expressions, false)...)
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	regexps := make([]*regexp.Regexp, 0, len(expressions))
+	for i, expression := range expressions {
+		re, err := regexp.Compile(expression)
+		if err != nil {
+			diags.AddAttributeError(
+				path.Root(attribute).AtListIndex(i),
+				"Invalid Regular Expression",
+				fmt.Sprintf("Unable to compile %q, got error: %s", expression, err),
+			)
+			continue
+		}
+		regexps = append(regexps, re)
+	}
+	return regexps, diags
+}
+
+// ignores reports whether the annotation or label key is ignored. field is
+// either "annotations" or "labels".
+func (f MetadataFilter) ignores(field, key string) bool {
+	var regexps []*regexp.Regexp
+	switch field {
+	case "annotations":
+		regexps = f.annotations
+	case "labels":
+		regexps = f.labels
+	}
+	for _, re := range regexps {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoresPath reports whether path points to an ignored annotation or label,
+// or below one.
+func (f MetadataFilter) ignoresPath(path []string) bool {
+	return len(path) >= 3 && path[0] == "metadata" && f.ignores(path[1], path[2])
+}
+
+// strip returns obj without the ignored annotations and labels. obj is not
+// modified.
+func (f MetadataFilter) strip(obj any) any {
+	return f.stripAt(nil, obj)
+}
+
+// stripAt returns value, found at path of an object, without the ignored
+// annotations and labels it contains. value is not modified.
+func (f MetadataFilter) stripAt(path []string, value any) any {
+	if len(f.annotations) == 0 && len(f.labels) == 0 {
+		return value
+	}
+
+	switch {
+	case len(path) == 0:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		metadata, ok := obj["metadata"]
+		if !ok {
+			return value
+		}
+		result := shallowCopy(obj)
+		result["metadata"] = f.stripAt([]string{"metadata"}, metadata)
+		return result
+	case len(path) == 1 && path[0] == "metadata":
+		metadata, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		result := shallowCopy(metadata)
+		for _, field := range []string{"annotations", "labels"} {
+			v, ok := metadata[field]
+			if !ok {
+				continue
+			}
+			stripped := f.stripAt([]string{"metadata", field}, v)
+			// Drop maps that only held ignored keys, so that they compare
+			// equal to missing ones.
+			if m, ok := stripped.(map[string]any); ok && len(m) == 0 {
+				delete(result, field)
+				continue
+			}
+			result[field] = stripped
+		}
+		return result
+	case len(path) == 2 && path[0] == "metadata":
+		keys, ok := value.(map[string]any)
+		if !ok {
+			return value
+		}
+		result := make(map[string]any, len(keys))
+		for key, v := range keys {
+			if !f.ignores(path[1], key) {
+				result[key] = v
+			}
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+func shallowCopy(m map[string]any) map[string]any {
+	result := make(map[string]any, len(m))
+	for k, v := range m {
+		result[k] = v
+	}
+	return result
+}
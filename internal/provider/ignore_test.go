@@ -0,0 +1,76 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestMetadataFilter(t *testing.T) {
+	ctx := context.Background()
+	filter, diags := NewMetadataFilter(ctx,
+		types.ListValueMust(types.StringType, []attr.Value{types.StringValue(`^example\.com/`)}),
+		types.ListValueMust(types.StringType, []attr.Value{types.StringValue(`^team$`)}),
+	)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+
+	var obj any
+	err := json.Unmarshal([]byte(`{
+  "metadata": {
+    "name": "test",
+    "annotations": {"example.com/revision": "2", "owner": "platform"},
+    "labels": {"team": "obs"}
+  },
+  "spec": {"template": {"metadata": {"annotations": {"example.com/revision": "2"}}}}
+}`), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := deepCopyJSON(obj)
+
+	var expected any
+	err = json.Unmarshal([]byte(`{
+  "metadata": {
+    "name": "test",
+    "annotations": {"owner": "platform"}
+  },
+  "spec": {"template": {"metadata": {"annotations": {"example.com/revision": "2"}}}}
+}`), &expected)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual := filter.strip(obj)
+	if !reflect.DeepEqual(expected, actual) {
+		t.Fatalf("expected %s, got %s", compactJSON(expected), compactJSON(actual))
+	}
+	if !reflect.DeepEqual(original, obj) {
+		t.Fatalf("strip modified its input: %s", compactJSON(obj))
+	}
+
+	if !filter.ignoresPath([]string{"metadata", "annotations", "example.com/revision"}) {
+		t.Fatal("expected the annotation to be ignored")
+	}
+	if filter.ignoresPath([]string{"metadata", "labels", "app"}) {
+		t.Fatal("expected the label not to be ignored")
+	}
+}
+
+func TestMetadataFilterInvalidRegexp(t *testing.T) {
+	_, diags := NewMetadataFilter(context.Background(),
+		types.ListValueMust(types.StringType, []attr.Value{types.StringValue(`valid`), types.StringValue(`(`)}),
+		types.ListNull(types.StringType),
+	)
+	if !diags.HasError() {
+		t.Fatal("expected an error")
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// evaluateJSONPath evaluates a kubectl-style JSONPath expression against obj.
+// The surrounding braces are optional, so ".spec.clusterIP" and
+// "{.spec.clusterIP}" are equivalent. A single match is returned as is, while
+// several matches, as produced by wildcards and filters, are returned as a
+// list. Missing fields evaluate to nil.
+func evaluateJSONPath(obj any, expression string) (any, error) {
+	j, err := parseJSONPath(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	results, err := j.FindResults(obj)
+	if err != nil {
+		return nil, fmt.Errorf("could not evaluate JSONPath expression %q: %w", expression, err)
+	}
+
+	var values []any
+	for _, result := range results {
+		for _, v := range result {
+			if v.IsValid() && v.CanInterface() {
+				values = append(values, v.Interface())
+			}
+		}
+	}
+
+	switch len(values) {
+	case 0:
+		return nil, nil
+	case 1:
+		return values[0], nil
+	default:
+		return values, nil
+	}
+}
+
+// parseJSONPath parses a JSONPath expression as accepted by evaluateJSONPath.
+func parseJSONPath(expression string) (*jsonpath.JSONPath, error) {
+	template := strings.TrimSpace(expression)
+	if !strings.HasPrefix(template, "{") {
+		template = "{" + template + "}"
+	}
+
+	j := jsonpath.New("").AllowMissingKeys(true)
+	if err := j.Parse(template); err != nil {
+		return nil, fmt.Errorf("invalid JSONPath expression %q: %w", expression, err)
+	}
+	return j, nil
+}
@@ -0,0 +1,67 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestEvaluateJSONPath(t *testing.T) {
+	var obj any
+	err := json.Unmarshal([]byte(`{
+  "metadata": {"name": "test", "annotations": {"example.com/team": "obs"}},
+  "spec": {"clusterIP": "10.0.0.1", "ports": [{"name": "http", "port": 80}, {"name": "https", "port": 443}]}
+}`), &obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		expression string
+		expected   any
+	}{
+		"without braces": {
+			expression: ".spec.clusterIP",
+			expected:   "10.0.0.1",
+		},
+		"with braces": {
+			expression: "{.spec.clusterIP}",
+			expected:   "10.0.0.1",
+		},
+		"escaped key": {
+			expression: `.metadata.annotations.example\.com/team`,
+			expected:   "obs",
+		},
+		"filter": {
+			expression: `.spec.ports[?(@.name=="https")].port`,
+			expected:   float64(443),
+		},
+		"wildcard": {
+			expression: ".spec.ports[*].name",
+			expected:   []any{"http", "https"},
+		},
+		"missing": {
+			expression: ".spec.loadBalancerIP",
+			expected:   nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := evaluateJSONPath(obj, tc.expression)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if !reflect.DeepEqual(tc.expected, actual) {
+				t.Fatalf("expected %#v, got %#v", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err := evaluateJSONPath(obj, ".spec.ports[?("); err == nil {
+		t.Fatal("expected an error for an invalid expression")
+	}
+}
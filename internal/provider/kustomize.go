@@ -0,0 +1,305 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// kustomizationFileNames are the names kustomize looks for in a kustomization
+// directory, in order of precedence.
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomization holds the fields of a kustomization file that carry patches.
+type kustomization struct {
+	Namespace             string           `json:"namespace,omitempty"`
+	Patches               []kustomizePatch `json:"patches,omitempty"`
+	PatchesJson6902       []kustomizePatch `json:"patchesJson6902,omitempty"`
+	PatchesStrategicMerge []string         `json:"patchesStrategicMerge,omitempty"`
+}
+
+// kustomizePatch is an entry of patches or patchesJson6902.
+type kustomizePatch struct {
+	Path   string           `json:"path,omitempty"`
+	Patch  string           `json:"patch,omitempty"`
+	Target *kustomizeTarget `json:"target,omitempty"`
+}
+
+// kustomizeTarget selects the objects a kustomize patch applies to. Name and
+// namespace are regular expressions matched against the whole value.
+type kustomizeTarget struct {
+	Group              string `json:"group,omitempty"`
+	Version            string `json:"version,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+	Name               string `json:"name,omitempty"`
+	Namespace          string `json:"namespace,omitempty"`
+	LabelSelector      string `json:"labelSelector,omitempty"`
+	AnnotationSelector string `json:"annotationSelector,omitempty"`
+}
+
+// kustomizeEntry is a single patch read from a kustomization.
+type kustomizeEntry struct {
+	// Source identifies the entry within the kustomization, e.g.
+	// patchesJson6902[1]. Documents of multi-document patch files are
+	// suffixed with their index, e.g. patches[0]#2.
+	Source string
+	// Type is the patch type, "strategic" or "json".
+	Type string
+	// Data is the patch as canonical JSON.
+	Data   []byte
+	Target kustomizeTarget
+}
+
+// loadKustomization reads the patches of the kustomization at path, either a
+// kustomization directory or a kustomization file. Targets without a
+// namespace get the namespace of the kustomization, if it sets one.
+func loadKustomization(path string) ([]kustomizeEntry, error) {
+	file, err := kustomizationFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(file)
+
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var k kustomization
+	if err := yaml.Unmarshal(b, &k); err != nil {
+		return nil, fmt.Errorf("could not decode %s: %w", file, err)
+	}
+
+	var entries []kustomizeEntry
+	add := func(source string, content []byte, target *kustomizeTarget, jsonOnly bool) error {
+		docs, err := yamlDocuments(content)
+		if err != nil {
+			return fmt.Errorf("%s: %w", source, err)
+		}
+		for i, doc := range docs {
+			docSource := source
+			if len(docs) > 1 {
+				docSource = fmt.Sprintf("%s#%d", source, i)
+			}
+
+			entry, err := newKustomizeEntry(docSource, doc, target, k.Namespace)
+			if err != nil {
+				return fmt.Errorf("%s: %w", docSource, err)
+			}
+			if jsonOnly && entry.Type != "json" {
+				return fmt.Errorf("%s: expected a JSON patch", docSource)
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	}
+
+	for i, p := range k.Patches {
+		content, err := patchContent(dir, p)
+		if err != nil {
+			return nil, fmt.Errorf("patches[%d]: %w", i, err)
+		}
+		if err := add(fmt.Sprintf("patches[%d]", i), content, p.Target, false); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, p := range k.PatchesJson6902 {
+		content, err := patchContent(dir, p)
+		if err != nil {
+			return nil, fmt.Errorf("patchesJson6902[%d]: %w", i, err)
+		}
+		if err := add(fmt.Sprintf("patchesJson6902[%d]", i), content, p.Target, true); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, p := range k.PatchesStrategicMerge {
+		// Entries are file paths, or inline patches like kustomize accepts.
+		content := []byte(p)
+		if !strings.Contains(p, "\n") {
+			content, err = os.ReadFile(filepath.Join(dir, p))
+			if err != nil {
+				return nil, fmt.Errorf("patchesStrategicMerge[%d]: %w", i, err)
+			}
+		}
+		if err := add(fmt.Sprintf("patchesStrategicMerge[%d]", i), content, nil, false); err != nil {
+			return nil, err
+		}
+	}
+
+	return entries, nil
+}
+
+// kustomizationFile returns the kustomization file at path, looking it up by
+// name when path is a directory.
+func kustomizationFile(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return path, nil
+	}
+
+	for _, name := range kustomizationFileNames {
+		file := filepath.Join(path, name)
+		if _, err := os.Stat(file); err == nil {
+			return file, nil
+		}
+	}
+	return "", fmt.Errorf("no kustomization file found in %s, expected one of %s", path, strings.Join(kustomizationFileNames, ", "))
+}
+
+// patchContent returns the patch of an entry of patches or patchesJson6902,
+// read from its path or given inline.
+func patchContent(dir string, p kustomizePatch) ([]byte, error) {
+	switch {
+	case p.Path != "" && p.Patch != "":
+		return nil, fmt.Errorf("only one of path and patch may be set")
+	case p.Path != "":
+		return os.ReadFile(filepath.Join(dir, p.Path))
+	case p.Patch != "":
+		return []byte(p.Patch), nil
+	default:
+		return nil, fmt.Errorf("one of path and patch must be set")
+	}
+}
+
+// yamlDocuments decodes the non-empty documents of a YAML or JSON stream.
+func yamlDocuments(content []byte) ([]any, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(content)))
+
+	var docs []any
+	for {
+		b, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return docs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		b, err = yaml.YAMLToJSON(b)
+		if err != nil {
+			return nil, err
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(b))
+		decoder.UseNumber()
+
+		var doc any
+		if err := decoder.Decode(&doc); err != nil {
+			return nil, err
+		}
+		if doc != nil {
+			docs = append(docs, doc)
+		}
+	}
+}
+
+// newKustomizeEntry builds the entry of a patch document. A strategic merge
+// patch without a target targets the object it names, and the fields naming
+// it are left out of the patch.
+func newKustomizeEntry(source string, doc any, target *kustomizeTarget, namespace string) (kustomizeEntry, error) {
+	entry := kustomizeEntry{Source: source}
+
+	switch patch := doc.(type) {
+	case []any:
+		if target == nil {
+			return entry, fmt.Errorf("a JSON patch requires a target")
+		}
+		entry.Type = "json"
+		entry.Target = *target
+	case map[string]any:
+		entry.Type = "strategic"
+		if target != nil {
+			entry.Target = *target
+		} else {
+			named, err := namedKustomizeTarget(patch)
+			if err != nil {
+				return entry, err
+			}
+			entry.Target = named
+		}
+		doc = withoutIdentity(patch)
+	default:
+		return entry, fmt.Errorf("expected a strategic merge patch object or a JSON patch list")
+	}
+
+	if entry.Target.Kind == "" {
+		return entry, fmt.Errorf("the target must set a kind")
+	}
+	if entry.Target.Namespace == "" {
+		entry.Target.Namespace = namespace
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return entry, err
+	}
+	entry.Data = b
+	return entry, nil
+}
+
+// namedKustomizeTarget returns the target naming the object a strategic merge
+// patch identifies through its apiVersion, kind and metadata.
+func namedKustomizeTarget(patch map[string]any) (kustomizeTarget, error) {
+	apiVersion, _ := patch["apiVersion"].(string)
+	kind, _ := patch["kind"].(string)
+	metadata, _ := patch["metadata"].(map[string]any)
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+
+	if kind == "" || name == "" {
+		return kustomizeTarget{}, fmt.Errorf("a strategic merge patch without a target must set kind and metadata.name")
+	}
+
+	gv, err := schema.ParseGroupVersion(apiVersion)
+	if err != nil {
+		return kustomizeTarget{}, fmt.Errorf("invalid apiVersion %q: %w", apiVersion, err)
+	}
+
+	return kustomizeTarget{
+		Group:     gv.Group,
+		Version:   gv.Version,
+		Kind:      kind,
+		Name:      regexp.QuoteMeta(name),
+		Namespace: regexp.QuoteMeta(namespace),
+	}, nil
+}
+
+// withoutIdentity returns a copy of a strategic merge patch without the
+// fields identifying its target, so that it can be applied to any object the
+// target matches.
+func withoutIdentity(patch map[string]any) map[string]any {
+	out := shallowCopy(patch)
+	delete(out, "apiVersion")
+	delete(out, "kind")
+
+	if metadata, ok := out["metadata"].(map[string]any); ok {
+		metadata = shallowCopy(metadata)
+		delete(metadata, "name")
+		delete(metadata, "namespace")
+		if len(metadata) == 0 {
+			delete(out, "metadata")
+		} else {
+			out["metadata"] = metadata
+		}
+	}
+	return out
+}
@@ -0,0 +1,676 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ resource.Resource = &KustomizePatchesResource{}
+var _ resource.ResourceWithModifyPlan = &KustomizePatchesResource{}
+
+func NewKustomizePatchesResource() resource.Resource {
+	return &KustomizePatchesResource{}
+}
+
+// KustomizePatchesResource defines the resource implementation. Each patch of
+// the kustomization is applied like a kubepatch_patch with the same type and
+// data, through patcher.
+type KustomizePatchesResource struct {
+	patcher *PatchResource
+}
+
+// KustomizePatchesResourceModel describes the resource data model.
+type KustomizePatchesResourceModel struct {
+	SourcePath      types.String `tfsdk:"source_path"`
+	Namespace       types.String `tfsdk:"namespace"`
+	FieldManager    types.String `tfsdk:"field_manager"`
+	DestroyBehavior types.String `tfsdk:"destroy_behavior"`
+
+	Patches  types.List   `tfsdk:"patches"`
+	InEffect types.Bool   `tfsdk:"in_effect"`
+	Id       types.String `tfsdk:"id"`
+}
+
+// KustomizePatchModel describes a patch of the kustomization and the objects
+// it was applied to.
+type KustomizePatchModel struct {
+	Source     types.String `tfsdk:"source"`
+	ApiVersion types.String `tfsdk:"api_version"`
+	Kind       types.String `tfsdk:"kind"`
+	Type       types.String `tfsdk:"type"`
+	Data       types.String `tfsdk:"data"`
+	Targets    types.List   `tfsdk:"targets"`
+	RevertData types.String `tfsdk:"revert_data"`
+}
+
+// kustomizePatchAttrTypes are the attribute types of KustomizePatchModel.
+var kustomizePatchAttrTypes = map[string]attr.Type{
+	"source":      types.StringType,
+	"api_version": types.StringType,
+	"kind":        types.StringType,
+	"type":        types.StringType,
+	"data":        types.StringType,
+	"targets":     types.ListType{ElemType: types.StringType},
+	"revert_data": types.StringType,
+}
+
+func (r *KustomizePatchesResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_kustomize_patches"
+}
+
+func (r *KustomizePatchesResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Applies the `patches`, `patchesJson6902` and `patchesStrategicMerge` of a kustomization to the live cluster. The targets of each patch are resolved against the cluster, and each patch is tracked separately in `patches`. Changes to the kustomization files and objects that start or stop matching a target are reported as drift.",
+
+		Attributes: map[string]schema.Attribute{
+			"source_path": schema.StringAttribute{
+				MarkdownDescription: "Path to a kustomization directory, or to a kustomization file. Patch files are resolved relative to it.",
+				Required:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of targets that set none, when the kustomization does not set `namespace` either. Such targets otherwise match objects in every namespace.",
+				Optional:            true,
+			},
+			"field_manager": schema.StringAttribute{
+				MarkdownDescription: "The name of the field manager used for the patches. Defaults to `kubepatch`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("kubepatch"),
+			},
+			"destroy_behavior": schema.StringAttribute{
+				MarkdownDescription: "What to do with the patched objects when this resource is destroyed, or when a patch or object is no longer targeted; one of [none revert]. `revert` restores the values each patch changed to what they were before it was first applied. Defaults to `revert`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("revert"),
+				Validators: []validator.String{
+					stringvalidator.OneOf("none", "revert"),
+				},
+			},
+			"patches": schema.ListNestedAttribute{
+				MarkdownDescription: "The patches of the kustomization, in the order they are applied.",
+				Computed:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"source": schema.StringAttribute{
+							MarkdownDescription: "The entry of the kustomization the patch comes from, e.g. `patchesJson6902[1]`. Documents of multi-document patch files are suffixed with their index, e.g. `patches[0]#2`.",
+							Computed:            true,
+						},
+						"api_version": schema.StringAttribute{
+							MarkdownDescription: "Kubernetes API group and version of the targets.",
+							Computed:            true,
+						},
+						"kind": schema.StringAttribute{
+							MarkdownDescription: "Kind of the targets.",
+							Computed:            true,
+						},
+						"type": schema.StringAttribute{
+							MarkdownDescription: "The type of the patch; one of [json strategic merge]. Strategic merge patches of custom resources are applied as `merge`, like kustomize does.",
+							Computed:            true,
+						},
+						"data": schema.StringAttribute{
+							MarkdownDescription: "The patch as JSON. The fields naming the target of a strategic merge patch are left out.",
+							Computed:            true,
+						},
+						"targets": schema.ListAttribute{
+							ElementType:         types.StringType,
+							MarkdownDescription: "The objects the patch was applied to, as `<namespace>/<name>` for namespaced resources and `<name>` for cluster-scoped ones.",
+							Computed:            true,
+						},
+						"revert_data": schema.StringAttribute{
+							MarkdownDescription: "JSON record of the values the patch changed on each target, as they were before it was first applied.",
+							Computed:            true,
+						},
+					},
+				},
+			},
+			"in_effect": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether every patch is still reflected in the live objects and the kustomization is unchanged. Set to false on refresh otherwise, in which case the next plan re-applies the patches.",
+				PlanModifiers: []planmodifier.Bool{
+					inEffectPlanModifier{},
+				},
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "The `source_path` of the kustomization.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+		},
+	}
+}
+
+func (r *KustomizePatchesResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.patcher = &PatchResource{client: providerData.Client, ignore: providerData.IgnoreMetadata}
+}
+
+func (r *KustomizePatchesResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	var data KustomizePatchesResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, &data, nil)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KustomizePatchesResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	var data KustomizePatchesResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if r.patcher == nil || r.patcher.client == nil {
+		resp.Diagnostics.AddError("Client Error", "Unable to read, the provider has no usable Kubernetes configuration")
+		return
+	}
+
+	var applied []KustomizePatchModel
+	resp.Diagnostics.Append(data.Patches.ElementsAs(ctx, &applied, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The patches stay those of the last apply, so that the next one can
+	// release what is no longer targeted.
+	current, err := r.resolve(ctx, data)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read the kustomization, got error: %s", err))
+		return
+	}
+
+	inEffect := samePatches(ctx, applied, current)
+	if !inEffect {
+		tflog.Info(ctx, "the patches of the kustomization or their targets have changed")
+	}
+
+	for _, patch := range applied {
+		patchInEffect, err := r.inEffect(ctx, patch)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to check whether %s is in effect, got error: %s", patch.Source.ValueString(), err))
+			return
+		}
+		inEffect = inEffect && patchInEffect
+	}
+	data.InEffect = types.BoolValue(inEffect)
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KustomizePatchesResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	var data, state KustomizePatchesResourceModel
+
+	// Read Terraform plan data into the model
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	var previous []KustomizePatchModel
+	resp.Diagnostics.Append(state.Patches.ElementsAs(ctx, &previous, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	resp.Diagnostics.Append(r.apply(ctx, &data, previous)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Save updated data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func (r *KustomizePatchesResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var data KustomizePatchesResourceModel
+
+	// Read Terraform prior state data into the model
+	resp.Diagnostics.Append(req.State.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.DestroyBehavior.ValueString() == "none" {
+		return
+	}
+
+	var patches []KustomizePatchModel
+	resp.Diagnostics.Append(data.Patches.ElementsAs(ctx, &patches, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Patches are released in reverse, so that values changed by several
+	// of them end up as they were before the first.
+	for i := len(patches) - 1; i >= 0; i-- {
+		resp.Diagnostics.Append(r.release(ctx, data, patches[i])...)
+	}
+}
+
+func (r *KustomizePatchesResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy, or when nothing changes.
+	if req.Plan.Raw.IsNull() || req.Plan.Raw.Equal(req.State.Raw) {
+		return
+	}
+
+	var data KustomizePatchesResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Re-applying patches that are no longer in effect changes them, which
+	// the framework only marks as unknown when the configuration changes.
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("patches"), types.ListUnknown(types.ObjectType{AttrTypes: kustomizePatchAttrTypes}))...)
+
+	if r.patcher == nil || r.patcher.client == nil || data.SourcePath.IsUnknown() || data.Namespace.IsUnknown() {
+		return
+	}
+
+	patches, err := r.resolve(ctx, data)
+	if meta.IsNoMatchError(err) {
+		tflog.Debug(ctx, "skipping planning the patches, a target resource is not served yet", map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("source_path"), "Invalid Kustomization", err.Error())
+		return
+	}
+
+	// The values to revert are recorded on apply.
+	for i := range patches {
+		patches[i].RevertData = types.StringUnknown()
+	}
+
+	value, diags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: kustomizePatchAttrTypes}, patches)
+	resp.Diagnostics.Append(diags...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("patches"), value)...)
+}
+
+// apply applies the patches planned in data, or those of the kustomization
+// when they could not be planned, and releases what the patches of the last
+// apply, previous, targeted and the new ones no longer do.
+func (r *KustomizePatchesResource) apply(ctx context.Context, data *KustomizePatchesResourceModel, previous []KustomizePatchModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.patcher == nil || r.patcher.client == nil {
+		diags.AddError("Client Error", "Unable to patch, the provider has no usable Kubernetes configuration")
+		return diags
+	}
+
+	var patches []KustomizePatchModel
+	if data.Patches.IsUnknown() || data.Patches.IsNull() {
+		var err error
+		patches, err = r.resolve(ctx, *data)
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to read the kustomization, got error: %s", err))
+			return diags
+		}
+	} else {
+		diags.Append(data.Patches.ElementsAs(ctx, &patches, false)...)
+
+		if diags.HasError() {
+			return diags
+		}
+	}
+
+	// A patch keeps the targets and revert data of the patch of the same
+	// source, unless it now targets another kind of object.
+	bySource := make(map[string]KustomizePatchModel, len(previous))
+	for _, patch := range previous {
+		bySource[patch.Source.ValueString()] = patch
+	}
+	kept := make(map[string]KustomizePatchModel, len(patches))
+	for _, patch := range patches {
+		if last, ok := bySource[patch.Source.ValueString()]; ok && last.ApiVersion.Equal(patch.ApiVersion) && last.Kind.Equal(patch.Kind) {
+			kept[patch.Source.ValueString()] = last
+		}
+	}
+
+	// Patches no longer part of the kustomization are released first, in
+	// reverse like on destroy, so that the patches applied next record the
+	// values as they are without them.
+	for i := len(previous) - 1; i >= 0; i-- {
+		patch := previous[i]
+		if _, ok := kept[patch.Source.ValueString()]; ok {
+			continue
+		}
+		tflog.Info(ctx, "patch is no longer part of the kustomization", map[string]any{"source": patch.Source.ValueString()})
+		diags.Append(r.release(ctx, *data, patch)...)
+	}
+	if diags.HasError() {
+		return diags
+	}
+
+	for i, patch := range patches {
+		mapping, err := r.patcher.client.KindMapping(patch.ApiVersion.ValueString(), patch.Kind.ValueString())
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to resolve the targets of %s, got error: %s", patch.Source.ValueString(), err))
+			return diags
+		}
+
+		var previousKeys []string
+		revertData := types.StringNull()
+		if last, ok := kept[patch.Source.ValueString()]; ok {
+			diags.Append(last.Targets.ElementsAs(ctx, &previousKeys, false)...)
+			revertData = last.RevertData
+		}
+
+		var keys []string
+		diags.Append(patch.Targets.ElementsAs(ctx, &keys, false)...)
+
+		if diags.HasError() {
+			return diags
+		}
+
+		objects, err := r.patcher.getObjects(ctx, mapping, keys)
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to read the targets of %s, got error: %s", patch.Source.ValueString(), err))
+			return diags
+		}
+
+		model := patchModel(*data, patch, revertData)
+		_, patchDiags := r.patcher.patchObjects(ctx, &model, mapping, objects, keys, previousKeys)
+		diags.Append(patchDiags...)
+
+		if diags.HasError() {
+			return diags
+		}
+		patches[i].RevertData = model.RevertData
+	}
+
+	value, listDiags := types.ListValueFrom(ctx, types.ObjectType{AttrTypes: kustomizePatchAttrTypes}, patches)
+	diags.Append(listDiags...)
+
+	data.Patches = value
+	data.InEffect = types.BoolValue(true)
+	data.Id = data.SourcePath
+	return diags
+}
+
+// release undoes patch on every object it targeted according to
+// destroy_behavior.
+func (r *KustomizePatchesResource) release(ctx context.Context, data KustomizePatchesResourceModel, patch KustomizePatchModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if r.patcher == nil || r.patcher.client == nil {
+		diags.AddError("Client Error", "Unable to release the patch, the provider has no usable Kubernetes configuration")
+		return diags
+	}
+
+	mapping, err := r.patcher.client.KindMapping(patch.ApiVersion.ValueString(), patch.Kind.ValueString())
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to resolve the targets of %s, got error: %s", patch.Source.ValueString(), err))
+		return diags
+	}
+
+	revertData, err := decodeRevertData(patch.RevertData)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to revert %s, got error: %s", patch.Source.ValueString(), err))
+		return diags
+	}
+
+	var keys []string
+	diags.Append(patch.Targets.ElementsAs(ctx, &keys, false)...)
+
+	model := patchModel(data, patch, patch.RevertData)
+	for _, key := range keys {
+		diags.Append(r.patcher.release(ctx, model, mapping, key, revertData)...)
+	}
+	return diags
+}
+
+// patchModel returns the kubepatch_patch equivalent of patch, used to apply
+// and release it. Like a patch spanning namespaces, it targets several
+// objects.
+func patchModel(data KustomizePatchesResourceModel, patch KustomizePatchModel, revertData types.String) PatchResourceModel {
+	return PatchResourceModel{
+		AllNamespaces:   types.BoolValue(true),
+		Type:            patch.Type,
+		Data:            NewPatchDataValue(patch.Data.ValueString()),
+		FieldManager:    data.FieldManager,
+		Force:           types.BoolValue(false),
+		DestroyBehavior: data.DestroyBehavior,
+		RevertData:      revertData,
+	}
+}
+
+// inEffect reports whether patch is still in effect on every object it was
+// applied to.
+func (r *KustomizePatchesResource) inEffect(ctx context.Context, patch KustomizePatchModel) (bool, error) {
+	mapping, err := r.patcher.client.KindMapping(patch.ApiVersion.ValueString(), patch.Kind.ValueString())
+	if err != nil {
+		return false, err
+	}
+
+	var keys []string
+	if diags := patch.Targets.ElementsAs(ctx, &keys, false); diags.HasError() {
+		return false, fmt.Errorf("could not read targets")
+	}
+
+	objects, err := r.patcher.getObjects(ctx, mapping, keys)
+	if err != nil {
+		return false, err
+	}
+	if len(objects) != len(keys) {
+		tflog.Info(ctx, "objects patched by the kustomization no longer exist", map[string]any{"source": patch.Source.ValueString()})
+		return false, nil
+	}
+
+	for _, live := range objects {
+		liveJSON, err := live.MarshalJSON()
+		if err != nil {
+			return false, err
+		}
+
+		inEffect, reason, err := patchInEffect(liveJSON, patch.Type.ValueString(), []byte(patch.Data.ValueString()), objectKind(mapping, live), r.patcher.ignore)
+		if err != nil {
+			return false, err
+		}
+		if !inEffect {
+			tflog.Info(ctx, "patch is no longer in effect", map[string]any{"source": patch.Source.ValueString(), "object": objectKey(live), "reason": reason})
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// resolve reads the patches of the kustomization and resolves their targets
+// against the cluster. Their revert data is left null.
+func (r *KustomizePatchesResource) resolve(ctx context.Context, data KustomizePatchesResourceModel) ([]KustomizePatchModel, error) {
+	entries, err := loadKustomization(data.SourcePath.ValueString())
+	if err != nil {
+		return nil, err
+	}
+
+	patches := make([]KustomizePatchModel, 0, len(entries))
+	for _, entry := range entries {
+		gk := k8sschema.GroupKind{Group: entry.Target.Group, Kind: entry.Target.Kind}
+		mapping, err := r.patcher.client.GroupKindMapping(gk, entry.Target.Version)
+		if err != nil {
+			return nil, fmt.Errorf("%s: could not resolve %s: %w", entry.Source, gk, err)
+		}
+
+		objects, err := r.targetObjects(ctx, mapping, entry.Target, data.Namespace.ValueString())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Source, err)
+		}
+
+		patchType := entry.Type
+		if _, ok := strategicDataStruct(mapping.GroupVersionKind); patchType == "strategic" && !ok {
+			// Custom resources do not support strategic merge patches, so
+			// kustomize falls back to a JSON merge patch.
+			patchType = "merge"
+		}
+
+		patches = append(patches, KustomizePatchModel{
+			Source:     types.StringValue(entry.Source),
+			ApiVersion: types.StringValue(mapping.GroupVersionKind.GroupVersion().String()),
+			Kind:       types.StringValue(mapping.GroupVersionKind.Kind),
+			Type:       types.StringValue(patchType),
+			Data:       types.StringValue(string(entry.Data)),
+			Targets:    targetsValue(targetKeys(objects)),
+			RevertData: types.StringNull(),
+		})
+	}
+	return patches, nil
+}
+
+// targetObjects returns the live objects matching a kustomize target, sorted
+// by target key. Targets without a namespace use defaultNamespace, or match
+// objects in every namespace when it is empty.
+func (r *KustomizePatchesResource) targetObjects(ctx context.Context, mapping *meta.RESTMapping, target kustomizeTarget, defaultNamespace string) ([]*unstructured.Unstructured, error) {
+	namespace := target.Namespace
+	if namespace == "" {
+		namespace = defaultNamespace
+	}
+
+	var client dynamic.ResourceInterface = r.patcher.client.Dynamic.Resource(mapping.Resource)
+	var namespaceRegex *regexp.Regexp
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace && namespace != "" {
+		if regexp.QuoteMeta(namespace) == namespace {
+			client = r.patcher.client.Dynamic.Resource(mapping.Resource).Namespace(namespace)
+		} else {
+			var err error
+			namespaceRegex, err = anchoredRegexp(namespace)
+			if err != nil {
+				return nil, fmt.Errorf("invalid target namespace: %w", err)
+			}
+		}
+	}
+
+	var nameRegex *regexp.Regexp
+	if target.Name != "" {
+		var err error
+		nameRegex, err = anchoredRegexp(target.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target name: %w", err)
+		}
+	}
+
+	var annotationSelector labels.Selector
+	if target.AnnotationSelector != "" {
+		var err error
+		annotationSelector, err = labels.Parse(target.AnnotationSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target annotationSelector: %w", err)
+		}
+	}
+
+	list, err := client.List(ctx, metav1.ListOptions{LabelSelector: target.LabelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if nameRegex != nil && !nameRegex.MatchString(obj.GetName()) {
+			continue
+		}
+		if namespaceRegex != nil && !namespaceRegex.MatchString(obj.GetNamespace()) {
+			continue
+		}
+		if annotationSelector != nil && !annotationSelector.Matches(labels.Set(obj.GetAnnotations())) {
+			continue
+		}
+		objects = append(objects, obj)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objectKey(objects[i]) < objectKey(objects[j])
+	})
+
+	return objects, nil
+}
+
+// anchoredRegexp compiles expr to match whole values only, like kustomize
+// matches target names and namespaces.
+func anchoredRegexp(expr string) (*regexp.Regexp, error) {
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+// samePatches reports whether the patches of the kustomization, and the
+// objects they target, are those that were applied.
+func samePatches(ctx context.Context, applied, current []KustomizePatchModel) bool {
+	if len(applied) != len(current) {
+		return false
+	}
+	for i := range applied {
+		a, c := applied[i], current[i]
+		if !a.Source.Equal(c.Source) || !a.ApiVersion.Equal(c.ApiVersion) || !a.Kind.Equal(c.Kind) || !a.Type.Equal(c.Type) || !a.Data.Equal(c.Data) || !a.Targets.Equal(c.Targets) {
+			return false
+		}
+	}
+	return true
+}
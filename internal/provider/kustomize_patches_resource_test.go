@@ -0,0 +1,347 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/terraform"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAccKustomizePatchesResource(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"kustomization.yaml": `
+namespace: default
+patches:
+- path: labels.yaml
+patchesJson6902:
+- target:
+    version: v1
+    kind: ConfigMap
+    name: kubepatch-kustomize-.*
+  patch: |-
+    - op: add
+      path: /data/level
+      value: debug
+`,
+		"labels.yaml": `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: kubepatch-kustomize-a
+  labels:
+    patched: "true"
+`,
+	})
+
+	config := providerConfig(t) + fmt.Sprintf(`
+resource "kubepatch_kustomize_patches" "test" {
+  source_path = %q
+}
+`, dir)
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+
+			clientset, err := getClientSet()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, name := range []string{"kubepatch-kustomize-a", "kubepatch-kustomize-b"} {
+				_, err = clientset.CoreV1().ConfigMaps("default").Create(context.TODO(), &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: name},
+					Data:       map[string]string{"level": "info"},
+				}, metav1.CreateOptions{})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			for _, name := range []string{"kubepatch-kustomize-a", "kubepatch-kustomize-b"} {
+				configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.TODO(), name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if configMap.Data["level"] != "info" {
+					return fmt.Errorf("expected %s to be reverted, got level %q", name, configMap.Data["level"])
+				}
+				if _, ok := configMap.Labels["patched"]; ok {
+					return fmt.Errorf("expected the label of %s to be reverted", name)
+				}
+				if err := clientset.CoreV1().ConfigMaps("default").Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_kustomize_patches.test",
+						tfjsonpath.New("patches").AtSliceIndex(0).AtMapKey("targets"),
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.StringExact("default/kubepatch-kustomize-a"),
+						}),
+					),
+					statecheck.ExpectKnownValue(
+						"kubepatch_kustomize_patches.test",
+						tfjsonpath.New("patches").AtSliceIndex(1).AtMapKey("targets"),
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.StringExact("default/kubepatch-kustomize-a"),
+							knownvalue.StringExact("default/kubepatch-kustomize-b"),
+						}),
+					),
+				},
+				Check: func(state *terraform.State) error {
+					clientset, err := getClientSet()
+					if err != nil {
+						return err
+					}
+
+					configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.TODO(), "kubepatch-kustomize-b", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					if configMap.Data["level"] != "debug" {
+						return fmt.Errorf("expected kubepatch-kustomize-b to be patched, got level %q", configMap.Data["level"])
+					}
+					return nil
+				},
+			},
+			// Removing a patch from the kustomization reverts it
+			{
+				PreConfig: func() {
+					err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte("namespace: default\npatches:\n- path: labels.yaml\n"), 0o600)
+					if err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("kubepatch_kustomize_patches.test", plancheck.ResourceActionUpdate),
+						plancheck.ExpectKnownValue(
+							"kubepatch_kustomize_patches.test",
+							tfjsonpath.New("patches"),
+							knownvalue.ListSizeExact(1),
+						),
+					},
+				},
+				Check: func(state *terraform.State) error {
+					clientset, err := getClientSet()
+					if err != nil {
+						return err
+					}
+
+					configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.TODO(), "kubepatch-kustomize-b", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					if configMap.Data["level"] != "info" {
+						return fmt.Errorf("expected kubepatch-kustomize-b to be reverted, got level %q", configMap.Data["level"])
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestKustomizePatchesResource(t *testing.T) {
+	server := newFakeAPIServer(t,
+		testDeployment("default", "web", 1),
+		testConfigMap("default", "web-a", map[string]any{"tier": "web"}, map[string]any{"level": "info"}),
+		testConfigMap("default", "web-b", map[string]any{"tier": "web"}, map[string]any{"level": "info"}),
+		testConfigMap("default", "db", map[string]any{"tier": "db"}, map[string]any{"level": "info"}),
+		testConfigMap("other", "web-c", map[string]any{"tier": "web"}, map[string]any{"level": "info"}),
+	)
+	h := newResourceHarness(t, NewKustomizePatchesResource(), server)
+
+	dir := writeFiles(t, map[string]string{
+		"kustomization.yaml": `
+namespace: default
+patches:
+- target:
+    version: v1
+    kind: ConfigMap
+    labelSelector: tier=web
+  patch: |-
+    - op: replace
+      path: /data/level
+      value: debug
+patchesJson6902:
+- target:
+    version: v1
+    kind: ConfigMap
+    name: db
+  patch: |-
+    - op: add
+      path: /data/pool
+      value: "10"
+patchesStrategicMerge:
+- deployment.yaml
+`,
+		"deployment.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:2
+`,
+	})
+	config := &KustomizePatchesResourceModel{SourcePath: types.StringValue(dir)}
+
+	h.apply(config)
+
+	var state KustomizePatchesResourceModel
+	h.get(&state)
+	var patches []KustomizePatchModel
+	if diags := state.Patches.ElementsAs(context.Background(), &patches, false); diags.HasError() {
+		t.Fatal(diags)
+	}
+	targets := map[string][]string{}
+	for _, patch := range patches {
+		var keys []string
+		patch.Targets.ElementsAs(context.Background(), &keys, false)
+		targets[patch.Source.ValueString()+" "+patch.Type.ValueString()] = keys
+	}
+	expectedTargets := map[string][]string{
+		"patches[0] json":                    {"default/web-a", "default/web-b"},
+		"patchesJson6902[0] json":            {"default/db"},
+		"patchesStrategicMerge[0] strategic": {"default/web"},
+	}
+	if fmt.Sprint(targets) != fmt.Sprint(expectedTargets) {
+		t.Fatalf("expected targets %v, got %v", expectedTargets, targets)
+	}
+
+	level := func(namespace, name string) string {
+		v, _, _ := unstructured.NestedString(server.get(configMapGVK, namespace, name).Object, "data", "level")
+		return v
+	}
+	for _, name := range []string{"web-a", "web-b"} {
+		if v := level("default", name); v != "debug" {
+			t.Errorf("expected %s to be patched by the selector, got level %q", name, v)
+		}
+	}
+	if v := level("other", "web-c"); v != "info" {
+		t.Errorf("expected the kustomization namespace to limit the selector, got level %q", v)
+	}
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "db").Object, "data", "pool"); v != "10" {
+		t.Errorf("expected db to be patched by patchesJson6902, got pool %q", v)
+	}
+	images := containerImages(t, server.get(deploymentGVK, "default", "web"))
+	if images["app"] != "app:2" || images["sidecar"] != "sidecar:1" {
+		t.Errorf("expected the strategic merge patch to replace the app image only, got %v", images)
+	}
+
+	h.refresh()
+	if h.planChanges(config) {
+		t.Error("expected no changes after apply")
+	}
+
+	h.destroy()
+	for _, name := range []string{"web-a", "web-b", "db"} {
+		if v := level("default", name); v != "info" {
+			t.Errorf("expected %s to be reverted, got level %q", name, v)
+		}
+	}
+	if _, found, _ := unstructured.NestedString(server.get(configMapGVK, "default", "db").Object, "data", "pool"); found {
+		t.Error("expected the pool added to db to be removed")
+	}
+	if images := containerImages(t, server.get(deploymentGVK, "default", "web")); images["app"] != "app:1" || images["sidecar"] != "sidecar:1" {
+		t.Errorf("expected the deployment to be reverted, got %v", images)
+	}
+}
+
+func TestKustomizePatchesResourceDrift(t *testing.T) {
+	server := newFakeAPIServer(t,
+		testConfigMap("default", "web-a", map[string]any{"tier": "web"}, map[string]any{"level": "info"}),
+		testConfigMap("default", "db", map[string]any{"tier": "db"}, map[string]any{"level": "info"}),
+	)
+	h := newResourceHarness(t, NewKustomizePatchesResource(), server)
+
+	selectorPatch := `
+- target:
+    version: v1
+    kind: ConfigMap
+    labelSelector: tier=web
+  patch: |-
+    - op: replace
+      path: /data/level
+      value: debug
+`
+	namePatch := `
+- target:
+    version: v1
+    kind: ConfigMap
+    name: db
+  patch: |-
+    - op: replace
+      path: /data/level
+      value: warn
+`
+	dir := writeFiles(t, map[string]string{"kustomization.yaml": "namespace: default\npatches:" + selectorPatch + namePatch})
+	config := &KustomizePatchesResourceModel{SourcePath: types.StringValue(dir)}
+
+	level := func(name string) string {
+		v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", name).Object, "data", "level")
+		return v
+	}
+
+	h.apply(config)
+
+	// An object starting to match the selector is drift, patched on apply.
+	server.add(t, testConfigMap("default", "web-b", map[string]any{"tier": "web"}, map[string]any{"level": "info"}))
+	h.refresh()
+	if !h.planChanges(config) {
+		t.Fatal("expected the new target to be planned")
+	}
+	h.apply(config)
+	if v := level("web-b"); v != "debug" {
+		t.Errorf("expected the new target to be patched, got level %q", v)
+	}
+
+	// A patch removed from the kustomization is reverted, the others kept.
+	if err := os.WriteFile(filepath.Join(dir, "kustomization.yaml"), []byte("namespace: default\npatches:"+namePatch), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	h.refresh()
+	h.apply(config)
+	for _, name := range []string{"web-a", "web-b"} {
+		if v := level(name); v != "info" {
+			t.Errorf("expected %s to be reverted, got level %q", name, v)
+		}
+	}
+	if v := level("db"); v != "warn" {
+		t.Errorf("expected db to stay patched, got level %q", v)
+	}
+}
@@ -0,0 +1,175 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadKustomization(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"kustomization.yaml": `
+namespace: apps
+patches:
+- path: replicas.yaml
+- target:
+    group: apps
+    version: v1
+    kind: Deployment
+    labelSelector: app=web
+  patch: |-
+    - op: add
+      path: /metadata/labels/patched
+      value: "true"
+patchesJson6902:
+- target:
+    version: v1
+    kind: ConfigMap
+    name: settings
+    namespace: other
+  path: settings.json
+patchesStrategicMerge:
+- |-
+  apiVersion: v1
+  kind: Service
+  metadata:
+    name: web
+  spec:
+    type: NodePort
+`,
+		"replicas.yaml": `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db.primary
+  namespace: data
+  labels:
+    tier: db
+spec:
+  replicas: 1
+`,
+		"settings.json": `[{"op": "replace", "path": "/data/level", "value": "debug"}]`,
+	})
+
+	entries, err := loadKustomization(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	expected := []kustomizeEntry{
+		{
+			Source: "patches[0]#0",
+			Type:   "strategic",
+			Data:   []byte(`{"spec":{"replicas":3}}`),
+			Target: kustomizeTarget{Group: "apps", Version: "v1", Kind: "Deployment", Name: "web", Namespace: "apps"},
+		},
+		{
+			Source: "patches[0]#1",
+			Type:   "strategic",
+			Data:   []byte(`{"metadata":{"labels":{"tier":"db"}},"spec":{"replicas":1}}`),
+			Target: kustomizeTarget{Group: "apps", Version: "v1", Kind: "StatefulSet", Name: `db\.primary`, Namespace: "data"},
+		},
+		{
+			Source: "patches[1]",
+			Type:   "json",
+			Data:   []byte(`[{"op":"add","path":"/metadata/labels/patched","value":"true"}]`),
+			Target: kustomizeTarget{Group: "apps", Version: "v1", Kind: "Deployment", Namespace: "apps", LabelSelector: "app=web"},
+		},
+		{
+			Source: "patchesJson6902[0]",
+			Type:   "json",
+			Data:   []byte(`[{"op":"replace","path":"/data/level","value":"debug"}]`),
+			Target: kustomizeTarget{Version: "v1", Kind: "ConfigMap", Name: "settings", Namespace: "other"},
+		},
+		{
+			Source: "patchesStrategicMerge[0]",
+			Type:   "strategic",
+			Data:   []byte(`{"spec":{"type":"NodePort"}}`),
+			Target: kustomizeTarget{Version: "v1", Kind: "Service", Name: "web", Namespace: "apps"},
+		},
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Fatalf("expected %+v, got %+v", expected, entries)
+	}
+
+	// A kustomization file can be given directly.
+	entries, err = loadKustomization(filepath.Join(dir, "kustomization.yaml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+	}
+}
+
+func TestLoadKustomizationErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		kustomization string
+		expected      string
+	}{
+		{
+			name:          "json patch without target",
+			kustomization: "patches:\n- patch: '[{\"op\": \"remove\", \"path\": \"/spec\"}]'\n",
+			expected:      "patches[0]: a JSON patch requires a target",
+		},
+		{
+			name:          "strategic patch in patchesJson6902",
+			kustomization: "patchesJson6902:\n- target: {version: v1, kind: ConfigMap, name: a}\n  patch: '{\"data\": {}}'\n",
+			expected:      "patchesJson6902[0]: expected a JSON patch",
+		},
+		{
+			name:          "strategic patch without name",
+			kustomization: "patches:\n- patch: '{\"kind\": \"ConfigMap\"}'\n",
+			expected:      "patches[0]: a strategic merge patch without a target must set kind and metadata.name",
+		},
+		{
+			name:          "path and patch",
+			kustomization: "patches:\n- path: a.yaml\n  patch: '{}'\n",
+			expected:      "patches[0]: only one of path and patch may be set",
+		},
+		{
+			name:          "missing file",
+			kustomization: "patchesStrategicMerge:\n- missing.yaml\n",
+			expected:      "patchesStrategicMerge[0]: ",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeFiles(t, map[string]string{"kustomization.yaml": tc.kustomization})
+
+			_, err := loadKustomization(dir)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if !strings.HasPrefix(err.Error(), tc.expected) {
+				t.Fatalf("expected error starting with %q, got %q", tc.expected, err)
+			}
+		})
+	}
+
+	if _, err := loadKustomization(t.TempDir()); err == nil || !strings.Contains(err.Error(), "no kustomization file found") {
+		t.Fatalf("expected a missing kustomization error, got %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/datasourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ datasource.DataSource = &ObjectDataSource{}
+var _ datasource.DataSourceWithConfigValidators = &ObjectDataSource{}
+
+func NewObjectDataSource() datasource.DataSource {
+	return &ObjectDataSource{}
+}
+
+// ObjectDataSource defines the data source implementation.
+type ObjectDataSource struct {
+	client *KubernetesClient
+	ignore MetadataFilter
+}
+
+// ObjectDataSourceModel describes the data source data model.
+type ObjectDataSourceModel struct {
+	ApiVersion types.String `tfsdk:"api_version"`
+	Kind       types.String `tfsdk:"kind"`
+	Resource   types.String `tfsdk:"resource"`
+	Namespace  types.String `tfsdk:"namespace"`
+	Name       types.String `tfsdk:"name"`
+	Paths      types.Map    `tfsdk:"paths"`
+
+	Object types.String `tfsdk:"object"`
+	Values types.Map    `tfsdk:"values"`
+	Id     types.String `tfsdk:"id"`
+}
+
+func (d *ObjectDataSource) Metadata(ctx context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_object"
+}
+
+func (d *ObjectDataSource) Schema(ctx context.Context, req datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Reads an object from the cluster, for example to use one of its values in a patch. The object is identified either by `api_version` and `kind`, or by `resource`.",
+
+		Attributes: map[string]schema.Attribute{
+			"api_version": schema.StringAttribute{
+				MarkdownDescription: "Kubernetes API group and version of the object, e.g. `apps/v1`. Required with `kind`. With `resource`, the preferred version served by the cluster is used when unset and recorded here.",
+				Optional:            true,
+				Computed:            true,
+			},
+			"kind": schema.StringAttribute{
+				MarkdownDescription: "Kind of the object, e.g. `Deployment`. Conflicts with `resource`.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("api_version")),
+				},
+			},
+			"resource": schema.StringAttribute{
+				MarkdownDescription: "Kubernetes API resource of the object, e.g. `deployments`, qualified the same way as for `kubepatch_patch`. Conflicts with `kind`.",
+				Optional:            true,
+			},
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Kubernetes namespace. Required for namespaced resources and ignored for cluster-scoped ones.",
+				Optional:            true,
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the object.",
+				Required:            true,
+			},
+			"paths": schema.MapAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Map of names to JSONPath expressions, e.g. `.spec.clusterIP`, selecting values of the object to expose in `values`.",
+				Optional:            true,
+			},
+			"object": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "JSON of the object, without `metadata.managedFields` and the annotations and labels matched by the provider's `ignore_annotations` and `ignore_labels`.",
+			},
+			"values": schema.MapAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Map of the names of `paths` to the values their expressions select. Strings are returned as they are and other values as JSON; expressions matching nothing, or ignored annotations and labels, map to null.",
+			},
+			"id": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Identifier of the object in the form `<apiVersion>/<resource>/<namespace>/<name>`, with an empty namespace for cluster-scoped resources.",
+			},
+		},
+	}
+}
+
+func (d *ObjectDataSource) ConfigValidators(ctx context.Context) []datasource.ConfigValidator {
+	return []datasource.ConfigValidator{
+		datasourcevalidator.ExactlyOneOf(
+			path.MatchRoot("kind"),
+			path.MatchRoot("resource"),
+		),
+	}
+}
+
+func (d *ObjectDataSource) Configure(ctx context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Data Source Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	d.client = providerData.Client
+	d.ignore = providerData.IgnoreMetadata
+}
+
+func (d *ObjectDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	var data ObjectDataSourceModel
+
+	// Read Terraform configuration data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if d.client == nil {
+		resp.Diagnostics.AddError("Client Error", "Unable to read object, the provider has no usable Kubernetes configuration")
+		return
+	}
+
+	var mapping *meta.RESTMapping
+	var err error
+	if !data.Kind.IsNull() {
+		mapping, err = d.client.KindMapping(data.ApiVersion.ValueString(), data.Kind.ValueString())
+	} else {
+		mapping, err = d.client.RESTMapping(data.ApiVersion.ValueString(), data.Resource.ValueString())
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to resolve the object's resource, got error: %s", err))
+		return
+	}
+
+	client, err := d.client.ResourceInterface(mapping, data.Namespace.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read object, got error: %s", err))
+		return
+	}
+
+	obj, err := client.Get(ctx, data.Name.ValueString(), metav1.GetOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read object, got error: %s", err))
+		return
+	}
+
+	doc, err := normalizeJSON(d.ignore.strip(withoutManagedFields(obj.Object)).(map[string]any))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read object, got error: %s", err))
+		return
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read object, got error: %s", err))
+		return
+	}
+
+	var paths map[string]string
+	resp.Diagnostics.Append(data.Paths.ElementsAs(ctx, &paths, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	values := make(map[string]attr.Value, len(paths))
+	for name, expression := range paths {
+		value, err := evaluateJSONPath(doc, expression)
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("paths").AtMapKey(name), "Invalid JSONPath", err.Error())
+			continue
+		}
+		if value == nil {
+			values[name] = types.StringNull()
+		} else {
+			values[name] = types.StringValue(formatValue(value))
+		}
+	}
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	data.ApiVersion = types.StringValue(mapping.GroupVersionKind.GroupVersion().String())
+	data.Object = types.StringValue(string(b))
+	data.Values = types.MapValueMust(types.StringType, values)
+	data.Id = types.StringValue(patchID(mapping, data.Namespace.ValueString(), data.Name.ValueString()))
+
+	// Save data into Terraform state
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
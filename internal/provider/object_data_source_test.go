@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+)
+
+func TestAccObjectDataSource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			// By kind
+			{
+				Config: providerConfig(t) + `
+data "kubepatch_object" "test" {
+  api_version = "v1"
+  kind        = "Service"
+  namespace   = "default"
+  name        = "kubernetes"
+  paths = {
+    cluster_ip = ".spec.clusterIP"
+    https_port = ".spec.ports[?(@.name==\"https\")].port"
+    missing    = ".spec.loadBalancerIP"
+  }
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"data.kubepatch_object.test",
+						tfjsonpath.New("id"),
+						knownvalue.StringExact("v1/services/default/kubernetes"),
+					),
+					statecheck.ExpectKnownValue(
+						"data.kubepatch_object.test",
+						tfjsonpath.New("values"),
+						knownvalue.MapExact(map[string]knownvalue.Check{
+							"cluster_ip": knownvalue.StringRegexp(regexp.MustCompile(`^[0-9a-f.:]+$`)),
+							"https_port": knownvalue.StringExact("443"),
+							"missing":    knownvalue.Null(),
+						}),
+					),
+				},
+			},
+			// By resource
+			{
+				Config: providerConfig(t) + `
+data "kubepatch_object" "test" {
+  resource = "clusterroles"
+  name     = "view"
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"data.kubepatch_object.test",
+						tfjsonpath.New("api_version"),
+						knownvalue.StringExact("rbac.authorization.k8s.io/v1"),
+					),
+					statecheck.ExpectKnownValue(
+						"data.kubepatch_object.test",
+						tfjsonpath.New("object"),
+						knownvalue.StringRegexp(regexp.MustCompile(`"kind":"ClusterRole"`)),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestObjectDataSourceIgnoreMetadata(t *testing.T) {
+	ctx := context.Background()
+
+	obj := testConfigMap("default", "settings", map[string]any{"team": "payments", "app": "billing"}, map[string]any{"key": "value"})
+	obj.SetAnnotations(map[string]string{"example.com/revision": "2", "owner": "platform"})
+	server := newFakeAPIServer(t, obj)
+
+	providerData := server.providerData(t)
+	ignore, diags := NewMetadataFilter(ctx,
+		types.ListValueMust(types.StringType, []attr.Value{types.StringValue(`^example\.com/`)}),
+		types.ListValueMust(types.StringType, []attr.Value{types.StringValue(`^team$`)}),
+	)
+	if diags.HasError() {
+		t.Fatalf("unexpected error: %v", diags)
+	}
+	providerData.IgnoreMetadata = ignore
+
+	d := NewObjectDataSource().(*ObjectDataSource)
+	var configureResp datasource.ConfigureResponse
+	d.Configure(ctx, datasource.ConfigureRequest{ProviderData: providerData}, &configureResp)
+	if configureResp.Diagnostics.HasError() {
+		t.Fatalf("unexpected configure diagnostics: %v", configureResp.Diagnostics)
+	}
+
+	var schemaResp datasource.SchemaResponse
+	d.Schema(ctx, datasource.SchemaRequest{}, &schemaResp)
+	objectType := schemaResp.Schema.Type().TerraformType(ctx).(tftypes.Object)
+	values := map[string]tftypes.Value{}
+	for name, typ := range objectType.AttributeTypes {
+		values[name] = tftypes.NewValue(typ, nil)
+	}
+	values["api_version"] = tftypes.NewValue(tftypes.String, "v1")
+	values["kind"] = tftypes.NewValue(tftypes.String, "ConfigMap")
+	values["namespace"] = tftypes.NewValue(tftypes.String, "default")
+	values["name"] = tftypes.NewValue(tftypes.String, "settings")
+	values["paths"] = tftypes.NewValue(tftypes.Map{ElementType: tftypes.String}, map[string]tftypes.Value{
+		"revision": tftypes.NewValue(tftypes.String, ".metadata.annotations.example\\.com/revision"),
+		"team":     tftypes.NewValue(tftypes.String, ".metadata.labels.team"),
+		"app":      tftypes.NewValue(tftypes.String, ".metadata.labels.app"),
+	})
+
+	resp := datasource.ReadResponse{State: tfsdk.State{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, nil)}}
+	d.Read(ctx, datasource.ReadRequest{Config: tfsdk.Config{Schema: schemaResp.Schema, Raw: tftypes.NewValue(objectType, values)}}, &resp)
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unexpected read diagnostics: %v", resp.Diagnostics)
+	}
+
+	var data ObjectDataSourceModel
+	if diags := resp.State.Get(ctx, &data); diags.HasError() {
+		t.Fatal(diags)
+	}
+	for _, key := range []string{"example.com/revision", `"team"`} {
+		if strings.Contains(data.Object.ValueString(), key) {
+			t.Errorf("expected %s to be ignored, got object %s", key, data.Object.ValueString())
+		}
+	}
+	if !strings.Contains(data.Object.ValueString(), `"owner":"platform"`) {
+		t.Errorf("expected the other annotations to be kept, got object %s", data.Object.ValueString())
+	}
+	expected := types.MapValueMust(types.StringType, map[string]attr.Value{
+		"revision": types.StringNull(),
+		"team":     types.StringNull(),
+		"app":      types.StringValue("billing"),
+	})
+	if !data.Values.Equal(expected) {
+		t.Errorf("expected values %s, got %s", expected, data.Values)
+	}
+}
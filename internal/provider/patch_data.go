@@ -0,0 +1,218 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/types/basetypes"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"sigs.k8s.io/yaml"
+)
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ basetypes.StringTypable = PatchDataType{}
+var _ basetypes.StringValuableWithSemanticEquals = PatchData{}
+
+// PatchDataType is the type of patch bodies, which may be written as JSON or
+// YAML. Bodies that normalize to the same JSON are semantically equal, so
+// reformatting a patch or reordering its keys does not show up as a change.
+type PatchDataType struct {
+	basetypes.StringType
+}
+
+func (t PatchDataType) String() string {
+	return "PatchDataType"
+}
+
+func (t PatchDataType) ValueType(ctx context.Context) attr.Value {
+	return PatchData{}
+}
+
+func (t PatchDataType) Equal(o attr.Type) bool {
+	other, ok := o.(PatchDataType)
+	if !ok {
+		return false
+	}
+	return t.StringType.Equal(other.StringType)
+}
+
+func (t PatchDataType) ValueFromString(ctx context.Context, in basetypes.StringValue) (basetypes.StringValuable, diag.Diagnostics) {
+	return PatchData{StringValue: in}, nil
+}
+
+func (t PatchDataType) ValueFromTerraform(ctx context.Context, in tftypes.Value) (attr.Value, error) {
+	attrValue, err := t.StringType.ValueFromTerraform(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	stringValue, ok := attrValue.(basetypes.StringValue)
+	if !ok {
+		return nil, fmt.Errorf("unexpected value type of %T", attrValue)
+	}
+
+	stringValuable, diags := t.ValueFromString(ctx, stringValue)
+	if diags.HasError() {
+		return nil, fmt.Errorf("unexpected error converting StringValue to StringValuable: %v", diags)
+	}
+	return stringValuable, nil
+}
+
+// PatchData is a patch body written as JSON or YAML.
+type PatchData struct {
+	basetypes.StringValue
+}
+
+// NewPatchDataValue returns a known PatchData holding value.
+func NewPatchDataValue(value string) PatchData {
+	return PatchData{StringValue: basetypes.NewStringValue(value)}
+}
+
+func (v PatchData) Type(ctx context.Context) attr.Type {
+	return PatchDataType{}
+}
+
+func (v PatchData) Equal(o attr.Value) bool {
+	other, ok := o.(PatchData)
+	if !ok {
+		return false
+	}
+	return v.StringValue.Equal(other.StringValue)
+}
+
+// StringSemanticEquals reports whether both bodies normalize to the same JSON.
+// Bodies that cannot be decoded are only equal to themselves; ValidateConfig
+// reports them.
+func (v PatchData) StringSemanticEquals(ctx context.Context, newValuable basetypes.StringValuable) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	newValue, ok := newValuable.(PatchData)
+	if !ok {
+		diags.AddError(
+			"Semantic Equality Check Error",
+			fmt.Sprintf("Expected value type %T, got: %T. Please report this issue to the provider developers.", v, newValuable),
+		)
+		return false, diags
+	}
+
+	a, err := v.Normalize()
+	if err != nil {
+		return false, diags
+	}
+	b, err := newValue.Normalize()
+	if err != nil {
+		return false, diags
+	}
+	return bytes.Equal(a, b), diags
+}
+
+// Normalize returns the body as canonical JSON, with sorted keys and without
+// insignificant whitespace.
+func (v PatchData) Normalize() ([]byte, error) {
+	return normalizePatchData(v.ValueString())
+}
+
+// normalizePatchData decodes data, written as JSON or YAML, and re-encodes it
+// as canonical JSON.
+func normalizePatchData(data string) ([]byte, error) {
+	b, err := yaml.YAMLToJSON([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not decode patch as JSON or YAML: %w", err)
+	}
+
+	// Numbers are kept as written, so that large integers keep their
+	// precision.
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+
+	var doc any
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("could not decode patch as JSON or YAML: %w", err)
+	}
+	return json.Marshal(doc)
+}
+
+// jsonPatchMembers lists the operations of RFC 6902 with the members each
+// requires besides op and path.
+var jsonPatchMembers = map[string][]string{
+	"add":     {"value"},
+	"remove":  nil,
+	"replace": {"value"},
+	"move":    {"from"},
+	"copy":    {"from"},
+	"test":    {"value"},
+}
+
+// validatePatch checks that body, as returned by Normalize, is structured as
+// a patch of patchType: a list of RFC 6902 operations for json, and an object
+// for the other types.
+func validatePatch(patchType string, body []byte) error {
+	if patchType != "json" {
+		var obj map[string]any
+		if err := json.Unmarshal(body, &obj); err != nil || obj == nil {
+			return fmt.Errorf("%s patches must be JSON objects", patchType)
+		}
+		return nil
+	}
+
+	var ops []json.RawMessage
+	if err := json.Unmarshal(body, &ops); err != nil {
+		return fmt.Errorf("json patches must be lists of RFC 6902 operations")
+	}
+
+	var errs []error
+	for i, raw := range ops {
+		if err := validateJSONPatchOperation(raw); err != nil {
+			errs = append(errs, fmt.Errorf("operation %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// validateJSONPatchOperation checks that raw is an RFC 6902 operation with
+// the members its op requires, and valid RFC 6901 pointers.
+func validateJSONPatchOperation(raw json.RawMessage) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil || members == nil {
+		return fmt.Errorf("must be a JSON object")
+	}
+
+	var op string
+	if _, ok := members["op"]; !ok {
+		return fmt.Errorf("missing op")
+	}
+	if err := json.Unmarshal(members["op"], &op); err != nil {
+		return fmt.Errorf("op must be a string")
+	}
+	required, ok := jsonPatchMembers[op]
+	if !ok {
+		return fmt.Errorf("unknown op %q, expected one of add, remove, replace, move, copy or test", op)
+	}
+
+	for _, name := range append([]string{"path"}, required...) {
+		value, ok := members[name]
+		if !ok {
+			return fmt.Errorf("%s requires %s", op, name)
+		}
+		if name == "value" {
+			continue
+		}
+
+		var pointer string
+		if err := json.Unmarshal(value, &pointer); err != nil {
+			return fmt.Errorf("%s must be a string", name)
+		}
+		if _, err := parsePointer(pointer); err != nil {
+			return fmt.Errorf("invalid %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+)
+
+func TestPatchDataSemanticEquals(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		a, b     string
+		expected bool
+	}{
+		{"key order", `{"a": 1, "b": {"c": true}}`, `{"b":{"c":true},"a":1}`, true},
+		{"yaml", "spec:\n  replicas: 3\n  paused: false\n", `{"spec": {"paused": false, "replicas": 3}}`, true},
+		{"json patch", `[{"op": "remove", "path": "/spec/replicas"}]`, "- path: /spec/replicas\n  op: remove\n", true},
+		{"array order", `{"args": ["a", "b"]}`, `{"args": ["b", "a"]}`, false},
+		{"different value", `{"replicas": 3}`, `{"replicas": "3"}`, false},
+		{"invalid", `{"replicas": `, `{"replicas": 3}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			equal, diags := NewPatchDataValue(tc.a).StringSemanticEquals(context.Background(), NewPatchDataValue(tc.b))
+			if diags.HasError() {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+			if equal != tc.expected {
+				t.Fatalf("expected semantic equality %t, got %t", tc.expected, equal)
+			}
+		})
+	}
+}
+
+func TestNormalizePatchData(t *testing.T) {
+	b, err := normalizePatchData("metadata:\n  labels:\n    team: obs\n  annotations:\n    generation: 9007199254740993\n")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if expected := `{"metadata":{"annotations":{"generation":9007199254740993},"labels":{"team":"obs"}}}`; string(b) != expected {
+		t.Fatalf("expected %s, got %s", expected, b)
+	}
+
+	if _, err := normalizePatchData("spec: [unterminated"); err == nil {
+		t.Fatal("expected an error for invalid YAML")
+	}
+}
+
+func TestValidatePatch(t *testing.T) {
+	for _, tc := range []struct {
+		patchType string
+		body      string
+		expected  string
+	}{
+		{"json", `[{"op": "add", "path": "/metadata/labels/team", "value": "obs"}, {"op": "remove", "path": "/spec/replicas"}]`, ""},
+		{"json", `[{"op": "move", "from": "/a", "path": "/b"}, {"op": "copy", "from": "/a~1b", "path": ""}, {"op": "test", "path": "/a", "value": null}]`, ""},
+		{"json", `[]`, ""},
+		{"json", `{"op": "add"}`, "json patches must be lists of RFC 6902 operations"},
+		{"json", `["add"]`, "operation 0: must be a JSON object"},
+		{"json", `[{"path": "/a", "value": 1}]`, "operation 0: missing op"},
+		{"json", `[{"op": 1, "path": "/a"}]`, "operation 0: op must be a string"},
+		{"json", `[{"op": "delete", "path": "/a"}]`, `operation 0: unknown op "delete", expected one of add, remove, replace, move, copy or test`},
+		{"json", `[{"op": "remove"}]`, "operation 0: remove requires path"},
+		{"json", `[{"op": "remove", "path": "a"}]`, `operation 0: invalid path: JSON pointer "a" must be empty or start with "/"`},
+		{"json", `[{"op": "remove", "path": "/a~2"}]`, `operation 0: invalid path: JSON pointer "/a~2" contains an invalid escape sequence`},
+		{"json", `[{"op": "replace", "path": "/a"}]`, "operation 0: replace requires value"},
+		{"json", `[{"op": "move", "path": "/a"}]`, "operation 0: move requires from"},
+		{"json", `[{"op": "copy", "from": 1, "path": "/a"}]`, "operation 0: from must be a string"},
+		{"json", `[{"op": "remove", "path": "/a"}, {"op": "copy", "path": "/b"}, {"op": "add", "path": "/c"}]`, "operation 1: copy requires from\noperation 2: add requires value"},
+		{"merge", `{"spec": {"replicas": 2}}`, ""},
+		{"merge", `[{"op": "add", "path": "/a", "value": 1}]`, "merge patches must be JSON objects"},
+		{"strategic", `null`, "strategic patches must be JSON objects"},
+		{"apply", `"spec"`, "apply patches must be JSON objects"},
+	} {
+		err := validatePatch(tc.patchType, []byte(tc.body))
+		if tc.expected == "" && err != nil {
+			t.Errorf("expected %s patch %s to be valid, got %s", tc.patchType, tc.body, err)
+		}
+		if tc.expected != "" && (err == nil || err.Error() != tc.expected) {
+			t.Errorf("expected %s patch %s to be rejected with %q, got %v", tc.patchType, tc.body, tc.expected, err)
+		}
+	}
+}
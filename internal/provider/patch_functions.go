@@ -0,0 +1,368 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ function.Function = &JSONPatchFunction{}
+var _ function.Function = &MergePatchFunction{}
+var _ function.Function = &JSONPatchDiffFunction{}
+var _ function.Function = &MergePatchDiffFunction{}
+var _ function.Function = &StrategicMergeFunction{}
+
+func NewJSONPatchFunction() function.Function {
+	return &JSONPatchFunction{}
+}
+
+// JSONPatchFunction applies an RFC 6902 JSON patch to a document.
+type JSONPatchFunction struct{}
+
+func (f *JSONPatchFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "json_patch"
+}
+
+func (f *JSONPatchFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Apply a JSON patch to a document",
+		MarkdownDescription: "Applies the RFC 6902 JSON patch `ops` to the JSON document `doc` and returns the patched document as JSON, the same way a `kubepatch_patch` of `type = \"json\"` changes an object.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "doc",
+				MarkdownDescription: "JSON document to patch.",
+			},
+			function.StringParameter{
+				Name:                "ops",
+				MarkdownDescription: "JSON array of RFC 6902 operations.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *JSONPatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var doc, ops string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &doc, &ops))
+
+	if resp.Error != nil {
+		return
+	}
+
+	if resp.Error = validateJSONArgument(0, doc); resp.Error != nil {
+		return
+	}
+
+	patch, err := jsonpatch.DecodePatch([]byte(ops))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid JSON patch: %s", err))
+		return
+	}
+
+	patched, err := patch.Apply([]byte(doc))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Unable to apply JSON patch: %s", err))
+		return
+	}
+
+	setJSONResult(ctx, resp, patched)
+}
+
+func NewMergePatchFunction() function.Function {
+	return &MergePatchFunction{}
+}
+
+// MergePatchFunction applies an RFC 7386 merge patch to a document.
+type MergePatchFunction struct{}
+
+func (f *MergePatchFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "merge_patch"
+}
+
+func (f *MergePatchFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Apply a merge patch to a document",
+		MarkdownDescription: "Applies the RFC 7386 merge patch `patch` to the JSON document `doc` and returns the patched document as JSON, the same way a `kubepatch_patch` of `type = \"merge\"` changes an object.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "doc",
+				MarkdownDescription: "JSON document to patch.",
+			},
+			function.StringParameter{
+				Name:                "patch",
+				MarkdownDescription: "JSON merge patch.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *MergePatchFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var doc, patch string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &doc, &patch))
+
+	if resp.Error != nil {
+		return
+	}
+
+	if resp.Error = validateJSONArgument(0, doc); resp.Error != nil {
+		return
+	}
+	if resp.Error = validateJSONArgument(1, patch); resp.Error != nil {
+		return
+	}
+
+	patched, err := jsonpatch.MergePatch([]byte(doc), []byte(patch))
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Unable to apply merge patch: %s", err))
+		return
+	}
+
+	setJSONResult(ctx, resp, patched)
+}
+
+func NewJSONPatchDiffFunction() function.Function {
+	return &JSONPatchDiffFunction{}
+}
+
+// JSONPatchDiffFunction computes the JSON patch turning one document into
+// another.
+type JSONPatchDiffFunction struct{}
+
+func (f *JSONPatchDiffFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "json_patch_diff"
+}
+
+func (f *JSONPatchDiffFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Compute the JSON patch between two documents",
+		MarkdownDescription: "Returns the RFC 6902 operations, as a JSON array, that turn the JSON document `old` into `new`. Arrays are compared by index.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "old",
+				MarkdownDescription: "JSON document before the change.",
+			},
+			function.StringParameter{
+				Name:                "new",
+				MarkdownDescription: "JSON document after the change.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *JSONPatchDiffFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var oldDoc, newDoc string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &oldDoc, &newDoc))
+
+	if resp.Error != nil {
+		return
+	}
+
+	var from, to any
+	if err := decodeJSON([]byte(oldDoc), &from); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid JSON: %s", err))
+		return
+	}
+	if err := decodeJSON([]byte(newDoc), &to); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid JSON: %s", err))
+		return
+	}
+
+	ops := diffJSON(from, to)
+	if ops == nil {
+		ops = []jsonPatchOperation{}
+	}
+
+	b, err := json.Marshal(ops)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, string(b)))
+}
+
+func NewMergePatchDiffFunction() function.Function {
+	return &MergePatchDiffFunction{}
+}
+
+// MergePatchDiffFunction computes the merge patch turning one document into
+// another.
+type MergePatchDiffFunction struct{}
+
+func (f *MergePatchDiffFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "merge_patch_diff"
+}
+
+func (f *MergePatchDiffFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Compute the merge patch between two documents",
+		MarkdownDescription: "Returns the RFC 7386 merge patch, as JSON, that turns the JSON object `old` into `new`. Removed fields are set to `null`.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "old",
+				MarkdownDescription: "JSON object before the change.",
+			},
+			function.StringParameter{
+				Name:                "new",
+				MarkdownDescription: "JSON object after the change.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *MergePatchDiffFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var oldDoc, newDoc string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &oldDoc, &newDoc))
+
+	if resp.Error != nil {
+		return
+	}
+
+	var from, to map[string]any
+	if err := decodeJSON([]byte(oldDoc), &from); err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Invalid JSON object: %s", err))
+		return
+	}
+	if err := decodeJSON([]byte(newDoc), &to); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid JSON object: %s", err))
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, compactJSON(diffMerge(from, to))))
+}
+
+func NewStrategicMergeFunction() function.Function {
+	return &StrategicMergeFunction{}
+}
+
+// StrategicMergeFunction applies a strategic merge patch to an object of a
+// built-in kind.
+type StrategicMergeFunction struct{}
+
+func (f *StrategicMergeFunction) Metadata(ctx context.Context, req function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "strategic_merge"
+}
+
+func (f *StrategicMergeFunction) Definition(ctx context.Context, req function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:             "Apply a strategic merge patch to an object",
+		MarkdownDescription: "Applies the strategic merge patch `patch` to the JSON object `original` of a built-in Kubernetes kind and returns the patched object as JSON, the same way a `kubepatch_patch` of `type = \"strategic\"` changes it. Lists are merged using the patch merge keys of the kind, for example containers, env, volumes and ports by `name`, and directives such as `$patch: delete` are honored.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:                "kind",
+				MarkdownDescription: "Kind of the object, e.g. `Deployment`. May be qualified with its group (`Ingress.networking.k8s.io`) or version and group (`Deployment.v1.apps`). Otherwise the `apiVersion` of `original`, or the preferred version of the first group defining the kind, is used.",
+			},
+			function.StringParameter{
+				Name:                "original",
+				MarkdownDescription: "JSON object to patch.",
+			},
+			function.StringParameter{
+				Name:                "patch",
+				MarkdownDescription: "JSON strategic merge patch.",
+			},
+		},
+		Return: function.StringReturn{},
+	}
+}
+
+func (f *StrategicMergeFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var kind, original, patch string
+
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &kind, &original, &patch))
+
+	if resp.Error != nil {
+		return
+	}
+
+	var obj map[string]any
+	if err := decodeJSON([]byte(original), &obj); err != nil {
+		resp.Error = function.NewArgumentFuncError(1, fmt.Sprintf("Invalid JSON object: %s", err))
+		return
+	}
+	if resp.Error = validateJSONArgument(2, patch); resp.Error != nil {
+		return
+	}
+
+	apiVersion, _ := obj["apiVersion"].(string)
+	gvk, err := builtinKind(kind, apiVersion)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, err.Error())
+		return
+	}
+
+	dataStruct, _ := strategicDataStruct(gvk)
+	patched, err := strategicpatch.StrategicMergePatch([]byte(original), []byte(patch), dataStruct)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(2, fmt.Sprintf("Unable to apply strategic merge patch: %s", err))
+		return
+	}
+
+	setJSONResult(ctx, resp, patched)
+}
+
+// builtinKind resolves kind, optionally qualified as kubectl accepts it, to a
+// kind known to the built-in scheme. apiVersion, when set, picks the version.
+func builtinKind(kind, apiVersion string) (schema.GroupVersionKind, error) {
+	fullySpecified, groupKind := schema.ParseKindArg(kind)
+	if fullySpecified != nil && scheme.Scheme.Recognizes(*fullySpecified) {
+		return *fullySpecified, nil
+	}
+
+	if gv, err := schema.ParseGroupVersion(apiVersion); err == nil && apiVersion != "" {
+		if groupKind.Group == "" || groupKind.Group == gv.Group {
+			if gvk := gv.WithKind(groupKind.Kind); scheme.Scheme.Recognizes(gvk) {
+				return gvk, nil
+			}
+		}
+	}
+
+	for _, gv := range scheme.Scheme.PrioritizedVersionsAllGroups() {
+		if groupKind.Group != "" && gv.Group != groupKind.Group {
+			continue
+		}
+		if gvk := gv.WithKind(groupKind.Kind); scheme.Scheme.Recognizes(gvk) {
+			return gvk, nil
+		}
+	}
+
+	return schema.GroupVersionKind{}, fmt.Errorf("%q is not a built-in Kubernetes kind; strategic merge patches are only supported for built-in kinds", kind)
+}
+
+// validateJSONArgument returns an error for the argument at position when
+// value is not valid JSON.
+func validateJSONArgument(position int64, value string) *function.FuncError {
+	var v any
+	if err := decodeJSON([]byte(value), &v); err != nil {
+		return function.NewArgumentFuncError(position, fmt.Sprintf("Invalid JSON: %s", err))
+	}
+	return nil
+}
+
+// setJSONResult sets the result of a function to b, re-encoded so that keys
+// are sorted and the output does not depend on the patch library.
+func setJSONResult(ctx context.Context, resp *function.RunResponse, b []byte) {
+	var v any
+	if err := decodeJSON(b, &v); err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, compactJSON(v)))
+}
@@ -0,0 +1,234 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// runFunction calls f with string arguments and returns its string result.
+func runFunction(t *testing.T, f function.Function, args ...string) (string, *function.FuncError) {
+	t.Helper()
+
+	values := make([]attr.Value, len(args))
+	for i, arg := range args {
+		values[i] = types.StringValue(arg)
+	}
+
+	resp := &function.RunResponse{Result: function.NewResultData(types.StringUnknown())}
+	f.Run(context.Background(), function.RunRequest{Arguments: function.NewArgumentsData(values)}, resp)
+	if resp.Error != nil {
+		return "", resp.Error
+	}
+	return resp.Result.Value().(types.String).ValueString(), nil
+}
+
+func TestPatchFunctions(t *testing.T) {
+	testCases := map[string]struct {
+		function function.Function
+		args     []string
+		expected string
+	}{
+		"json_patch": {
+			function: NewJSONPatchFunction(),
+			args:     []string{`{"spec": {"replicas": 1, "args": ["--a"]}}`, `[{"op": "replace", "path": "/spec/replicas", "value": 3}, {"op": "add", "path": "/spec/args/-", "value": "--b"}]`},
+			expected: `{"spec":{"args":["--a","--b"],"replicas":3}}`,
+		},
+		"merge_patch": {
+			function: NewMergePatchFunction(),
+			args:     []string{`{"metadata": {"labels": {"app": "test", "team": "obs"}}}`, `{"metadata": {"labels": {"team": null, "tier": "web"}}}`},
+			expected: `{"metadata":{"labels":{"app":"test","tier":"web"}}}`,
+		},
+		"json_patch_diff": {
+			function: NewJSONPatchDiffFunction(),
+			args:     []string{`{"spec": {"replicas": 1, "paused": true}}`, `{"spec": {"replicas": 3, "args": ["--a"]}}`},
+			expected: `[{"op":"add","path":"/spec/args","value":["--a"]},{"op":"remove","path":"/spec/paused"},{"op":"replace","path":"/spec/replicas","value":3}]`,
+		},
+		"json_patch_diff equal": {
+			function: NewJSONPatchDiffFunction(),
+			args:     []string{`{"a": 1}`, `{"a": 1}`},
+			expected: `[]`,
+		},
+		"json_patch large integer": {
+			function: NewJSONPatchFunction(),
+			args:     []string{`{"spec": {"id": 9007199254740993}}`, `[{"op": "add", "path": "/spec/replicas", "value": 3}]`},
+			expected: `{"spec":{"id":9007199254740993,"replicas":3}}`,
+		},
+		"merge_patch large integer": {
+			function: NewMergePatchFunction(),
+			args:     []string{`{"spec": {"id": 1}}`, `{"spec": {"id": 9007199254740993}}`},
+			expected: `{"spec":{"id":9007199254740993}}`,
+		},
+		"json_patch_diff large integer": {
+			function: NewJSONPatchDiffFunction(),
+			args:     []string{`{"id": 9007199254740992}`, `{"id": 9007199254740993}`},
+			expected: `[{"op":"replace","path":"/id","value":9007199254740993}]`,
+		},
+		"merge_patch_diff large integer": {
+			function: NewMergePatchDiffFunction(),
+			args:     []string{`{"id": 9007199254740992}`, `{"id": 9007199254740993}`},
+			expected: `{"id":9007199254740993}`,
+		},
+		"merge_patch_diff": {
+			function: NewMergePatchDiffFunction(),
+			args:     []string{`{"spec": {"replicas": 1, "paused": true}}`, `{"spec": {"replicas": 3}}`},
+			expected: `{"spec":{"paused":null,"replicas":3}}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := runFunction(t, tc.function, tc.args...)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestPatchFunctionsInvalidArguments(t *testing.T) {
+	testCases := map[string]struct {
+		function function.Function
+		args     []string
+		argument int64
+	}{
+		"json_patch invalid document": {
+			function: NewJSONPatchFunction(),
+			args:     []string{`{`, `[]`},
+			argument: 0,
+		},
+		"json_patch invalid ops": {
+			function: NewJSONPatchFunction(),
+			args:     []string{`{}`, `{"op": "add"}`},
+			argument: 1,
+		},
+		"json_patch failing test op": {
+			function: NewJSONPatchFunction(),
+			args:     []string{`{"a": 1}`, `[{"op": "test", "path": "/a", "value": 2}]`},
+			argument: 1,
+		},
+		"merge_patch invalid patch": {
+			function: NewMergePatchFunction(),
+			args:     []string{`{}`, `not json`},
+			argument: 1,
+		},
+		"merge_patch_diff invalid old": {
+			function: NewMergePatchDiffFunction(),
+			args:     []string{`[]`, `{}`},
+			argument: 0,
+		},
+		"json_patch_diff invalid new": {
+			function: NewJSONPatchDiffFunction(),
+			args:     []string{`{}`, `[`},
+			argument: 1,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			_, err := runFunction(t, tc.function, tc.args...)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if err.FunctionArgument == nil || *err.FunctionArgument != tc.argument {
+				t.Fatalf("expected an error for argument %d, got %s", tc.argument, err)
+			}
+		})
+	}
+}
+
+func TestStrategicMergeFunction(t *testing.T) {
+	deployment := `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {"name": "test"},
+  "spec": {"template": {"spec": {
+    "containers": [
+      {"name": "app", "image": "app:1", "env": [{"name": "A", "value": "1"}, {"name": "B", "value": "2"}], "ports": [{"containerPort": 80, "name": "http"}]},
+      {"name": "sidecar", "image": "sidecar:1"}
+    ],
+    "volumes": [{"name": "data", "emptyDir": {}}]
+  }}}
+}`
+
+	testCases := map[string]struct {
+		kind     string
+		original string
+		patch    string
+		expected string
+	}{
+		"containers and env merged by name": {
+			kind:     "Deployment",
+			original: deployment,
+			patch:    `{"spec": {"template": {"spec": {"containers": [{"name": "app", "image": "app:2", "env": [{"name": "B", "value": "3"}]}]}}}}`,
+			expected: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"template":{"spec":{"containers":[{"env":[{"name":"A","value":"1"},{"name":"B","value":"3"}],"image":"app:2","name":"app","ports":[{"containerPort":80,"name":"http"}]},{"image":"sidecar:1","name":"sidecar"}],"volumes":[{"emptyDir":{},"name":"data"}]}}}}`,
+		},
+		"delete directive and volume added": {
+			kind:     "Deployment.v1.apps",
+			original: deployment,
+			patch:    `{"spec": {"template": {"spec": {"containers": [{"name": "sidecar", "$patch": "delete"}], "volumes": [{"name": "cache", "emptyDir": {}}]}}}}`,
+			expected: `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"test"},"spec":{"template":{"spec":{"containers":[{"env":[{"name":"A","value":"1"},{"name":"B","value":"2"}],"image":"app:1","name":"app","ports":[{"containerPort":80,"name":"http"}]}],"volumes":[{"emptyDir":{},"name":"cache"},{"emptyDir":{},"name":"data"}]}}}}`,
+		},
+		"ports merged by container port": {
+			kind:     "Pod",
+			original: `{"spec": {"containers": [{"name": "app", "ports": [{"containerPort": 80, "name": "http"}]}]}}`,
+			patch:    `{"spec": {"containers": [{"name": "app", "ports": [{"containerPort": 443, "name": "https"}]}]}}`,
+			expected: `{"spec":{"containers":[{"name":"app","ports":[{"containerPort":443,"name":"https"},{"containerPort":80,"name":"http"}]}]}}`,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, err := runFunction(t, NewStrategicMergeFunction(), tc.kind, tc.original, tc.patch)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestStrategicMergeFunctionUnknownKind(t *testing.T) {
+	_, err := runFunction(t, NewStrategicMergeFunction(), "Certificate", `{"apiVersion": "cert-manager.io/v1"}`, `{}`)
+	if err == nil || err.FunctionArgument == nil || *err.FunctionArgument != 0 {
+		t.Fatalf("expected an error for the kind argument, got %v", err)
+	}
+}
+
+func TestBuiltinKind(t *testing.T) {
+	testCases := map[string]struct {
+		kind       string
+		apiVersion string
+		expected   string
+	}{
+		"kind":                        {kind: "Deployment", expected: "apps/v1, Kind=Deployment"},
+		"core kind":                   {kind: "ConfigMap", expected: "/v1, Kind=ConfigMap"},
+		"group":                       {kind: "Ingress.networking.k8s.io", expected: "networking.k8s.io/v1, Kind=Ingress"},
+		"version and group":           {kind: "HorizontalPodAutoscaler.v2.autoscaling", expected: "autoscaling/v2, Kind=HorizontalPodAutoscaler"},
+		"api version of original":     {kind: "HorizontalPodAutoscaler", apiVersion: "autoscaling/v2", expected: "autoscaling/v2, Kind=HorizontalPodAutoscaler"},
+		"unknown api version ignored": {kind: "Deployment", apiVersion: "apps/v9", expected: "apps/v1, Kind=Deployment"},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			gvk, err := builtinKind(tc.kind, tc.apiVersion)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if gvk.String() != tc.expected {
+				t.Fatalf("expected %s, got %s", tc.expected, gvk)
+			}
+		})
+	}
+}
@@ -5,26 +5,46 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/mapplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	k8stypes "k8s.io/apimachinery/pkg/types"
-	"k8s.io/client-go/kubernetes"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &PatchResource{}
 var _ resource.ResourceWithImportState = &PatchResource{}
+var _ resource.ResourceWithValidateConfig = &PatchResource{}
+var _ resource.ResourceWithModifyPlan = &PatchResource{}
+
+// defaultTimeout bounds each operation when no timeouts are configured.
+const defaultTimeout = 10 * time.Minute
 
 func NewPatchResource() resource.Resource {
 	return &PatchResource{}
@@ -32,18 +52,52 @@ func NewPatchResource() resource.Resource {
 
 // PatchResource defines the resource implementation.
 type PatchResource struct {
-	client *kubernetes.Clientset
+	client  *KubernetesClient
+	clients *clientCache
+	ignore  MetadataFilter
+	retry   retryPolicy
 }
 
 // PatchResourceModel describes the resource data model.
 type PatchResourceModel struct {
-	Namespace types.String `tfsdk:"namespace"`
-	Resource  types.String `tfsdk:"resource"`
-	Name      types.String `tfsdk:"name"`
-	Type      types.String `tfsdk:"type"`
-	Data      types.String `tfsdk:"data"`
-	Triggers  types.Map    `tfsdk:"triggers"`
-	Id        types.String `tfsdk:"id"`
+	Namespace         types.String         `tfsdk:"namespace"`
+	AllNamespaces     types.Bool           `tfsdk:"all_namespaces"`
+	NamespaceSelector types.String         `tfsdk:"namespace_selector"`
+	ApiVersion        types.String         `tfsdk:"api_version"`
+	Resource          types.String         `tfsdk:"resource"`
+	Name              types.String         `tfsdk:"name"`
+	Selector          []PatchSelectorModel `tfsdk:"selector"`
+	Subresource       types.String         `tfsdk:"subresource"`
+	Type              types.String         `tfsdk:"type"`
+	Data              PatchData            `tfsdk:"data"`
+	Triggers          types.Map            `tfsdk:"triggers"`
+
+	FieldManager types.String `tfsdk:"field_manager"`
+	Force        types.Bool   `tfsdk:"force"`
+
+	DestroyBehavior types.String `tfsdk:"destroy_behavior"`
+	DestroyData     PatchData    `tfsdk:"destroy_data"`
+	RevertData      types.String `tfsdk:"revert_data"`
+
+	PlannedObject  types.String `tfsdk:"planned_object"`
+	PlannedChanges types.List   `tfsdk:"planned_changes"`
+
+	ResultPaths     types.List   `tfsdk:"result_paths"`
+	Result          types.String `tfsdk:"result"`
+	Uid             types.String `tfsdk:"uid"`
+	ResourceVersion types.String `tfsdk:"resource_version"`
+	Generation      types.Int64  `tfsdk:"generation"`
+	Targets         types.List   `tfsdk:"targets"`
+
+	Preconditions []PatchPreconditionsModel `tfsdk:"preconditions"`
+	Retry         []RetryModel              `tfsdk:"retry"`
+	Cluster       []ClusterModel            `tfsdk:"cluster"`
+
+	Wait     []PatchWaitModel `tfsdk:"wait"`
+	Timeouts timeouts.Value   `tfsdk:"timeouts"`
+
+	InEffect types.Bool   `tfsdk:"in_effect"`
+	Id       types.String `tfsdk:"id"`
 }
 
 func (r *PatchResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -57,57 +111,77 @@ func (r *PatchResource) Schema(ctx context.Context, req resource.SchemaRequest,
 
 		Attributes: map[string]schema.Attribute{
 			"namespace": schema.StringAttribute{
-				MarkdownDescription: "Kubernetes namespace",
-				Required:            true,
+				MarkdownDescription: "Kubernetes namespace. Required for namespaced resources unless `all_namespaces` or `namespace_selector` is set, and ignored for cluster-scoped ones.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("namespace_selector")),
+				},
+			},
+			"all_namespaces": schema.BoolAttribute{
+				MarkdownDescription: "Applies the patch to the targeted objects in every namespace, including namespaces created later, which show up as drift on the next plan. When true, conflicts with `namespace` and `namespace_selector`.",
+				Optional:            true,
+			},
+			"namespace_selector": schema.StringAttribute{
+				MarkdownDescription: "Label selector, in the syntax accepted by `kubectl --selector`, on the Namespace objects. Applies the patch to the targeted objects in every matching namespace, including namespaces matching later, which show up as drift on the next plan. Conflicts with `namespace`.",
+				Optional:            true,
+			},
+			"api_version": schema.StringAttribute{
+				MarkdownDescription: "Kubernetes API group and version of the resource, e.g. `apps/v1` or `cert-manager.io/v1`. When unset the preferred version served by the cluster is used and recorded here.",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"resource": schema.StringAttribute{
-				MarkdownDescription: "Kubernetes API resource",
+				MarkdownDescription: "Kubernetes API resource, e.g. `deployments`. May be qualified with its group (`certificates.cert-manager.io`) or version and group (`deployments.v1.apps`) like kubectl accepts. Any resource served by the cluster, including custom resources, can be patched.",
 				Required:            true,
-				Validators: []validator.String{
-					stringvalidator.OneOf(
-						"bindings",
-						"componentstatuses",
-						"configmaps",
-						"endpoints",
-						"events",
-						"limitranges",
-						"namespaces",
-						"nodes",
-						"persistentvolumeclaims",
-						"persistentvolumes",
-						"pods",
-						"podtemplates",
-						"replicationcontrollers",
-						"resourcequotas",
-						"secrets",
-						"serviceaccounts",
-						"services",
-						"controllerrevisions",
-						"daemonsets",
-						"deployments",
-						"replicasets",
-						"statefulsets",
-						"cronjobs",
-						"jobs",
-						"validatingwebhookconfigurations",
-					),
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
 				},
 			},
 			"name": schema.StringAttribute{
-				MarkdownDescription: "Kubernetes API resource name",
-				Required:            true,
+				MarkdownDescription: "Kubernetes API resource name. Exactly one of `name` or a `selector` block must be set.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"subresource": schema.StringAttribute{
+				MarkdownDescription: "Subresource of the object to patch instead of the object itself, e.g. `status`, `scale` or `ephemeralcontainers`. Must be reported as patchable by the cluster's discovery for `resource`. Drift is detected on, and `result` holds, the subresource as served by the API server, which for `scale` is an `autoscaling/v1` Scale.",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"type": schema.StringAttribute{
-				MarkdownDescription: "The type of patch being provided; one of [json merge strategic]",
+				MarkdownDescription: "The type of patch being provided; one of [json merge strategic apply]. `apply` uses server-side apply, making `field_manager` the owner of the fields in `data`.",
 				Required:            true,
 				Validators: []validator.String{
-					stringvalidator.OneOf("json", "merge", "strategic"),
+					stringvalidator.OneOf("json", "merge", "strategic", "apply"),
 				},
 			},
 			"data": schema.StringAttribute{
-				MarkdownDescription: "The patch to be applied to the resource JSON file.",
+				MarkdownDescription: "The patch to be applied to the resource, as JSON or YAML. It is normalized to canonical JSON, so changes in formatting or key order are not reported as changes. For `apply` this is the partial object configuration; `apiVersion`, `kind`, `metadata.name` and `metadata.namespace` are filled in from the target when omitted. Its structure, a list of RFC 6902 operations for `json` and an object for the other types, is validated during plan.",
+				CustomType:          PatchDataType{},
 				Required:            true,
 			},
+			"field_manager": schema.StringAttribute{
+				MarkdownDescription: "The name of the field manager used for the patch. Defaults to `kubepatch`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("kubepatch"),
+			},
+			"force": schema.BoolAttribute{
+				MarkdownDescription: "Whether server-side apply takes ownership of fields owned by other field managers instead of failing with a conflict. Only used when `type` is `apply`. Defaults to false.",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
 			"triggers": schema.MapAttribute{
 				MarkdownDescription: "Map of arbitrary keys and values that, when changed, will trigger a redeployment.",
 				ElementType:         types.StringType,
@@ -116,14 +190,220 @@ func (r *PatchResource) Schema(ctx context.Context, req resource.SchemaRequest,
 					mapplanmodifier.RequiresReplace(),
 				},
 			},
+			"destroy_behavior": schema.StringAttribute{
+				MarkdownDescription: "What to do with the patched object when this resource is destroyed; one of [none revert custom]. `revert` restores the values the patch changed to what they were before it was first applied, or for `apply` releases ownership of the applied fields, `custom` applies `destroy_data` and `none` leaves the object as it is. Defaults to `revert`.",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString("revert"),
+				Validators: []validator.String{
+					stringvalidator.OneOf("none", "revert", "custom"),
+				},
+			},
+			"destroy_data": schema.StringAttribute{
+				MarkdownDescription: "The patch applied to the resource on destroy when `destroy_behavior` is `custom`, as JSON or YAML. It is of the same `type` as `data`.",
+				CustomType:          PatchDataType{},
+				Optional:            true,
+			},
+			"revert_data": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "JSON record of the values the patch changed on each target, as they were before it was first applied. Used to revert the patch on destroy, or when an object is no longer targeted.",
+			},
+			"planned_object": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "JSON of the object as it looks after the patch, obtained through a server-side dry-run during plan. With a `selector` or namespace selection, a JSON object mapping each of the `targets` to its object instead. Unknown when the dry-run cannot be performed at plan time, for example because the object does not exist yet.",
+			},
+			"planned_changes": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "Human-readable list of the fields of the object changed by the patch, as found by the dry-run in `planned_object`. With a `selector` or namespace selection, each change is prefixed with the target it applies to.",
+			},
+			"result_paths": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "JSONPath expressions, e.g. `.spec.clusterIP`, selecting the parts of the patched object to expose in `result`. When unset `result` holds the whole object.",
+				Optional:            true,
+			},
+			"result": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "JSON of the patched object as returned by the API server, without `metadata.managedFields`. When `result_paths` is set, a JSON object mapping each expression to the value it selects instead; expressions matching several values map to a list. With a `selector` or namespace selection, a JSON object mapping each of the `targets` to its result.",
+			},
+			"uid": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "UID of the patched object. Null with a `selector` or namespace selection.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"resource_version": schema.StringAttribute{
+				Computed:            true,
+				MarkdownDescription: "Resource version of the patched object. Null with a `selector` or namespace selection.",
+			},
+			"generation": schema.Int64Attribute{
+				Computed:            true,
+				MarkdownDescription: "Generation of the patched object. Null with a `selector` or namespace selection.",
+			},
+			"targets": schema.ListAttribute{
+				ElementType:         types.StringType,
+				Computed:            true,
+				MarkdownDescription: "The objects the patch was applied to, as `<namespace>/<name>` for namespaced resources and `<name>` for cluster-scoped ones. With a `selector` or namespace selection, objects that start matching are reported as drift and patched by the next apply, and objects that stop matching are released according to `destroy_behavior`.",
+			},
+			"in_effect": schema.BoolAttribute{
+				Computed:            true,
+				MarkdownDescription: "Whether the patch is still reflected in the live object. Set to false on refresh when the object has drifted, or when the targeted objects have changed, in which case the next plan re-applies the patch.",
+				PlanModifiers: []planmodifier.Bool{
+					inEffectPlanModifier{},
+				},
+			},
 			"id": schema.StringAttribute{
 				Computed:            true,
-				MarkdownDescription: "Example identifier",
+				MarkdownDescription: "Identifier of the patch in the form `<apiVersion>/<resource>/<namespace>/<name>`, with an empty namespace for cluster-scoped resources. The namespace is `*` with `all_namespaces` or `namespace_selector`, and the name is `*` with a `selector`.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
 		},
+		Blocks: map[string]schema.Block{
+			"selector": schema.ListNestedBlock{
+				MarkdownDescription: "Applies the patch to every object of the resource in the targeted namespaces matching the selector, instead of the object named by `name`. Every given criterion must match.",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"label_selector": schema.StringAttribute{
+							MarkdownDescription: "Label selector in the syntax accepted by `kubectl --selector`, e.g. `team=obs,tier!=batch`.",
+							Optional:            true,
+						},
+						"field_selector": schema.StringAttribute{
+							MarkdownDescription: "Field selector in the syntax accepted by `kubectl --field-selector`, e.g. `status.phase=Running`. Only fields supported by the resource may be used.",
+							Optional:            true,
+						},
+						"name_regex": schema.StringAttribute{
+							MarkdownDescription: "Regular expression, in RE2 syntax, matched against the name of the object, e.g. `^fluent-bit-`. Use `^` and `$` to match the whole name.",
+							Optional:            true,
+						},
+					},
+				},
+			},
+			"preconditions": schema.ListNestedBlock{
+				MarkdownDescription: "Conditions every target object must meet for the patch to be applied. When one is not met the apply fails before any object is patched, so that a patch written against one shape of an object, e.g. one addressing `containers/0`, does not land on the wrong element after the object changed. Preconditions are checked whenever the patch is applied, including when it is re-applied after drift.",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"resource_version": schema.StringAttribute{
+							MarkdownDescription: "Resource version the object must have. It is also sent with the patch, so that the API server rejects it if the object is modified in between. Only valid with `name`.",
+							Optional:            true,
+						},
+						"uid": schema.StringAttribute{
+							MarkdownDescription: "UID the object must have, to avoid patching an object that was deleted and recreated under the same name. Only valid with `name`.",
+							Optional:            true,
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"field": schema.ListNestedBlock{
+							MarkdownDescription: "Require a field of the object to have a value.",
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"path": schema.StringAttribute{
+										MarkdownDescription: "JSONPath expression selecting the field, e.g. `.spec.template.spec.containers[0].name`.",
+										Required:            true,
+									},
+									"value": schema.StringAttribute{
+										MarkdownDescription: "The value the field must have. Values other than strings are compared as compact JSON, and missing fields as the empty string.",
+										Required:            true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"cluster": clusterBlock(),
+			"retry": schema.ListNestedBlock{
+				MarkdownDescription: "Overrides the provider's `retry` settings for the Kubernetes API requests of this resource.",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"max_attempts": schema.Int64Attribute{
+							MarkdownDescription: "Number of times a request is sent, including the first time. 1 disables retries.",
+							Optional:            true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(1),
+							},
+						},
+						"initial_backoff": schema.StringAttribute{
+							MarkdownDescription: "Delay before the first retry, doubled with every further retry.",
+							Optional:            true,
+							Validators: []validator.String{
+								durationValidator{},
+							},
+						},
+						"max_backoff": schema.StringAttribute{
+							MarkdownDescription: "Upper bound of the delay between retries, unless the API server asks for a longer one through `Retry-After`.",
+							Optional:            true,
+							Validators: []validator.String{
+								durationValidator{},
+							},
+						},
+					},
+				},
+			},
+			"wait": schema.ListNestedBlock{
+				MarkdownDescription: "Conditions to wait for after patching before the apply completes. Every condition must hold. The wait counts towards the `create` and `update` timeouts, which default to 10 minutes; on timeout the last observed `status` of the object is reported.",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"rollout": schema.BoolAttribute{
+							MarkdownDescription: "Wait for the rollout of a Deployment, StatefulSet or DaemonSet to complete, using the same checks as `kubectl rollout status`.",
+							Optional:            true,
+						},
+					},
+					Blocks: map[string]schema.Block{
+						"field": schema.ListNestedBlock{
+							MarkdownDescription: "Wait for a field of the object to have a value.",
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"path": schema.StringAttribute{
+										MarkdownDescription: "JSONPath expression selecting the field, e.g. `.status.phase`.",
+										Required:            true,
+									},
+									"value": schema.StringAttribute{
+										MarkdownDescription: "The value to wait for. Values other than strings are compared as compact JSON.",
+										Required:            true,
+									},
+								},
+							},
+						},
+						"condition": schema.ListNestedBlock{
+							MarkdownDescription: "Wait for an entry of `status.conditions` to have a status.",
+							NestedObject: schema.NestedBlockObject{
+								Attributes: map[string]schema.Attribute{
+									"type": schema.StringAttribute{
+										MarkdownDescription: "The condition type, e.g. `Ready`.",
+										Required:            true,
+									},
+									"status": schema.StringAttribute{
+										MarkdownDescription: "The condition status to wait for. Defaults to `True`.",
+										Optional:            true,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			"timeouts": timeouts.Block(ctx, timeouts.Opts{
+				Create: true,
+				Read:   true,
+				Update: true,
+				Delete: true,
+			}),
+		},
 	}
 }
 
@@ -133,18 +413,21 @@ func (r *PatchResource) Configure(ctx context.Context, req resource.ConfigureReq
 		return
 	}
 
-	client, ok := req.ProviderData.(*kubernetes.Clientset)
+	providerData, ok := req.ProviderData.(*ProviderData)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *http.Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
 	}
 
-	r.client = client
+	r.client = providerData.Client
+	r.clients = providerData.Clients
+	r.ignore = providerData.IgnoreMetadata
+	r.retry = providerData.Retry
 }
 
 func (r *PatchResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
@@ -157,98 +440,482 @@ func (r *PatchResource) Create(ctx context.Context, req resource.CreateRequest,
 		return
 	}
 
-	// If applicable, this is a great opportunity to initialize any necessary
-	// provider client data and make a call using it.
-	// httpResp, err := r.client.Do(httpReq)
-	// if err != nil {
-	//     resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create example, got error: %s", err))
-	//     return
-	// }
+	createTimeout, diags := data.Timeouts.Create(ctx, defaultTimeout)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, createTimeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, resp.Diagnostics, "create", createTimeout)
+	}()
 
-	// For the purposes of this example code, hardcoding a response value to
-	// save into the Terraform state.
-	data.Id = types.StringValue("example-id")
+	ctx = r.withRetry(ctx, data)
+	resp.Diagnostics.Append(r.useCluster(data)...)
 
-	// Write logs using the tflog package
-	// Documentation: https://terraform.io/plugin/log
-	tflog.Trace(ctx, "created a resource")
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	err := r.patch(ctx, data)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+	resp.Diagnostics.Append(r.patch(ctx, &data, nil)...)
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	data.InEffect = types.BoolValue(true)
 
 	// Save data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+
+	// The patch is applied at this point, so the state is saved even when the
+	// wait fails, tainting the resource.
+	err := r.wait(ctx, data)
+	if err != nil {
+		resp.Diagnostics.AddError("Wait Failed", fmt.Sprintf("The patch was applied, but the object did not reach the expected state: %s", err))
+	}
+}
+
+// patch applies the patch to every target object through patchObjects and
+// sets the computed attributes describing them.
+func (r *PatchResource) patch(ctx context.Context, data *PatchResourceModel, previous []string) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	mapping, err := r.mapping(*data)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+		return diags
+	}
+
+	err = r.checkSubresource(*data, mapping)
+	if err != nil {
+		diags.AddAttributeError(path.Root("subresource"), "Invalid Subresource", err.Error())
+		return diags
+	}
+
+	objects, err := r.plannedObjects(ctx, *data, mapping)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+		return diags
+	}
+
+	keys := targetKeys(objects)
+	if data.selectsMany() && !data.Targets.IsUnknown() && !data.Targets.IsNull() {
+		// Planned objects deleted since the plan keep their place, so that
+		// the targets match the plan; the next refresh reports them as gone.
+		diags.Append(data.Targets.ElementsAs(ctx, &keys, false)...)
+	}
+
+	results, patchDiags := r.patchObjects(ctx, data, mapping, objects, keys, previous)
+	diags.Append(patchDiags...)
+
+	if diags.HasError() {
+		return diags
+	}
+
+	err = setResult(ctx, data, results, r.ignore)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+		return diags
+	}
+	data.ApiVersion = types.StringValue(mapping.GroupVersionKind.GroupVersion().String())
+	data.Id = types.StringValue(patchID(mapping, data.targetNamespace(), data.targetName()))
+	data.Targets = targetsValue(keys)
+
+	// The planned object is only unknown when the dry-run could not be
+	// performed during plan.
+	if data.PlannedObject.IsUnknown() || data.PlannedChanges.IsUnknown() {
+		plannedObject, plannedChanges, err := plannedValues(*data, objects, results, r.ignore)
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+			return diags
+		}
+		data.PlannedObject = plannedObject
+		data.PlannedChanges = plannedChanges
+	}
+
+	return diags
+}
+
+// patchObjects applies the patch to objects, first recording the values it
+// is about to change in data.RevertData. keys are the target keys of the
+// patch, and objects in previous, the targets of the last apply, that are not
+// among them are released according to destroy_behavior.
+func (r *PatchResource) patchObjects(ctx context.Context, data *PatchResourceModel, mapping *meta.RESTMapping, objects []*unstructured.Unstructured, keys, previous []string) ([]*unstructured.Unstructured, diag.Diagnostics) {
+	var diags diag.Diagnostics
+
+	revertData, err := decodeRevertData(data.RevertData)
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+		return nil, diags
+	}
+
+	// Every object is checked before any is patched, so that unmet
+	// preconditions leave the objects untouched.
+	if len(data.Preconditions) > 0 {
+		for _, live := range objects {
+			err := checkPreconditions(live, data.Preconditions[0])
+			var unmet *preconditionError
+			if errors.As(err, &unmet) {
+				diags.AddError("Precondition Failed", fmt.Sprintf("The patch was not applied because %s does not meet its preconditions: %s", unmet.key, unmet.message))
+				return nil, diags
+			}
+			if err != nil {
+				diags.AddError("Client Error", fmt.Sprintf("Unable to check the preconditions of %s, got error: %s", objectKey(live), err))
+				return nil, diags
+			}
+		}
+	}
+
+	results := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, live := range objects {
+		result, err := r.patchObject(ctx, *data, mapping, live, revertData)
+		var conflict *applyConflictError
+		if errors.As(err, &conflict) {
+			diags.AddError("Server-Side Apply Conflict", conflict.Error())
+			return nil, diags
+		}
+		var unmet *preconditionError
+		if errors.As(err, &unmet) {
+			diags.AddError("Precondition Failed", fmt.Sprintf("The patch was not applied because %s no longer meets its preconditions: %s", unmet.key, unmet.message))
+			return nil, diags
+		}
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to patch %s, got error: %s", objectKey(live), err))
+			return nil, diags
+		}
+		results = append(results, result)
+	}
+
+	_, released := targetChanges(previous, keys)
+	for _, key := range released {
+		tflog.Info(ctx, "object is no longer targeted by the patch", map[string]any{"object": key})
+		diags.Append(r.release(ctx, *data, mapping, key, revertData)...)
+		delete(revertData, key)
+	}
+	if len(released) > 0 && data.DestroyBehavior.ValueString() == "none" {
+		diags.AddWarning(
+			"Objects No Longer Targeted",
+			fmt.Sprintf("The following objects are no longer targeted and have been left patched, since destroy_behavior is \"none\": %s", strings.Join(released, ", ")),
+		)
+	}
+	if diags.HasError() {
+		return nil, diags
+	}
+
+	if data.Type.ValueString() == "apply" {
+		// Server-side apply is reverted by releasing field ownership, so
+		// there is nothing to record.
+		data.RevertData = types.StringNull()
+	} else {
+		data.RevertData, err = encodeRevertData(revertData)
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to record the values changed by the patch, got error: %s", err))
+			return nil, diags
+		}
+	}
+
+	return results, diags
+}
+
+// patchObject applies the patch to live, first recording the values it is
+// about to change in revertData.
+func (r *PatchResource) patchObject(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping, live *unstructured.Unstructured, revertData map[string][]revertEntry) (*unstructured.Unstructured, error) {
+	client, err := r.client.ResourceInterface(mapping, live.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := data.Data.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	body, options, err := patchRequest(data, mapping, live.GetNamespace(), live.GetName(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	if data.Type.ValueString() != "apply" {
+		key := objectKey(live)
+		entries, err := recordRevertData(live.Object, data.Type.ValueString(), body, revertData[key])
+		if err != nil {
+			return nil, fmt.Errorf("could not record the values changed by the patch: %w", err)
+		}
+		revertData[key] = entries
+	}
+
+	resourceVersion := ""
+	if len(data.Preconditions) > 0 {
+		resourceVersion = data.Preconditions[0].ResourceVersion.ValueString()
+	}
+	if resourceVersion != "" {
+		body, err = pinResourceVersion(data.Type.ValueString(), body, resourceVersion)
+		if err != nil {
+			return nil, err
+		}
+		// A conflict means the object was modified, which retrying cannot
+		// fix.
+		ctx = withoutConflictRetries(ctx)
+	}
+
+	result, err := client.Patch(ctx, live.GetName(), patchType(data.Type.ValueString()), body, options, data.subresources()...)
+	if isApplyConflict(err) {
+		return nil, &applyConflictError{err: err, managedFields: live.GetManagedFields()}
+	}
+	if err != nil && resourceVersion != "" {
+		// The API server reports a modified object as a conflict, or as a
+		// failed test operation for JSON patches, so look at the object to
+		// tell which happened.
+		current, getErr := client.Get(ctx, live.GetName(), metav1.GetOptions{})
+		if getErr == nil && current.GetResourceVersion() != resourceVersion {
+			return nil, &preconditionError{key: objectKey(live), message: fmt.Sprintf("it was modified after its preconditions were checked; resource version is %q, expected %q", current.GetResourceVersion(), resourceVersion)}
+		}
+	}
+	return result, err
+}
+
+// withRetry returns ctx with the retry policy of the provider overridden by
+// the retry block of data, if any.
+func (r *PatchResource) withRetry(ctx context.Context, data PatchResourceModel) context.Context {
+	if len(data.Retry) == 0 {
+		return ctx
+	}
+	policy, err := newRetryPolicy(r.retry, data.Retry)
+	if err != nil {
+		// Durations are validated with the configuration.
+		return ctx
+	}
+	return withRetryPolicy(ctx, policy)
+}
+
+// timeoutDiagnostics returns diags with its errors replaced by a timeout error
+// when ctx ran out of time, as they then describe requests cut short by the
+// timeout of operation rather than errors of the API server.
+func timeoutDiagnostics(ctx context.Context, diags diag.Diagnostics, operation string, timeout time.Duration) diag.Diagnostics {
+	if !diags.HasError() || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return diags
+	}
+
+	var out diag.Diagnostics
+	var details []string
+	for _, d := range diags {
+		if d.Severity() != diag.SeverityError {
+			out.Append(d)
+			continue
+		}
+		details = append(details, d.Detail())
+	}
+	out.AddError("Timeout", fmt.Sprintf("The %s did not complete within its timeout of %s, which can be raised in the timeouts block. Last error: %s", operation, timeout, strings.Join(details, "; ")))
+	return out
+}
+
+// useCluster points the client of the resource at the cluster of the cluster
+// block of data, if any.
+func (r *PatchResource) useCluster(data PatchResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if len(data.Cluster) == 0 || r.clients == nil {
+		return diags
+	}
+
+	client, clientDiags := r.clients.client(data.Cluster)
+	if client == nil {
+		// The provider only warns about an invalid configuration, as it may
+		// be configured before its cluster exists, but the cluster block is
+		// used straight away.
+		for _, d := range clientDiags {
+			diags.AddAttributeError(path.Root("cluster"), "Invalid Cluster Configuration", fmt.Sprintf("Unable to configure a client for the cluster, got error: %s", d.Detail()))
+		}
+		return diags
+	}
+	r.client = client
+	return diags
+}
+
+// patchRequest builds the body and options of the request sending body, of
+// the resource's patch type, to the named object.
+func patchRequest(data PatchResourceModel, mapping *meta.RESTMapping, namespace, name string, body []byte) ([]byte, metav1.PatchOptions, error) {
+	options := metav1.PatchOptions{
+		FieldManager: data.FieldManager.ValueString(),
+	}
+
+	if data.Type.ValueString() == "apply" {
+		gvk := mapping.GroupVersionKind
+		if data.Subresource.ValueString() == "scale" {
+			// The scale subresource is served as an autoscaling/v1 Scale.
+			gvk = autoscalingv1.SchemeGroupVersion.WithKind("Scale")
+		}
+
+		var err error
+		body, err = applyConfiguration(body, gvk, namespace, name)
+		if err != nil {
+			return nil, options, err
+		}
+		options.Force = data.Force.ValueBoolPointer()
+	}
+
+	return body, options, nil
+}
+
+// setResult sets the computed attributes describing the patched objects. With
+// a selector, result maps the target key of each object to its value, and the
+// attributes describing a single object are null.
+func setResult(ctx context.Context, data *PatchResourceModel, objects []*unstructured.Unstructured, ignore MetadataFilter) error {
+	var doc any
+	if data.selectsMany() {
+		values := make(map[string]any, len(objects))
+		for _, obj := range objects {
+			value, err := resultValue(ctx, *data, obj, ignore)
+			if err != nil {
+				return err
+			}
+			values[objectKey(obj)] = value
+		}
+		doc = values
+	} else if len(objects) == 1 {
+		var err error
+		doc, err = resultValue(ctx, *data, objects[0], ignore)
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	data.Result = types.StringValue(string(b))
+
+	if data.selectsMany() || len(objects) != 1 {
+		data.Uid = types.StringNull()
+		data.ResourceVersion = types.StringNull()
+		data.Generation = types.Int64Null()
+		return nil
+	}
+
+	obj := objects[0]
+	data.Uid = types.StringValue(string(obj.GetUID()))
+	data.ResourceVersion = types.StringValue(obj.GetResourceVersion())
+	data.Generation = types.Int64Value(obj.GetGeneration())
+	return nil
+}
+
+// resultValue returns the value of obj exposed in result: the object itself,
+// or the values selected by result_paths.
+func resultValue(ctx context.Context, data PatchResourceModel, obj *unstructured.Unstructured, ignore MetadataFilter) (any, error) {
+	doc, err := normalizeJSON(withoutManagedFields(obj.Object))
+	if err != nil {
+		return nil, err
+	}
+	doc = ignore.strip(doc)
+
+	if data.ResultPaths.IsNull() || data.ResultPaths.IsUnknown() {
+		return doc, nil
+	}
+
+	var expressions []string
+	if diags := data.ResultPaths.ElementsAs(ctx, &expressions, false); diags.HasError() {
+		return nil, fmt.Errorf("could not read result_paths")
+	}
+
+	values := make(map[string]any, len(expressions))
+	for _, expression := range expressions {
+		value, err := evaluateJSONPath(doc, expression)
+		if err != nil {
+			return nil, err
+		}
+		values[expression] = value
+	}
+	return values, nil
+}
+
+func withoutManagedFields(obj map[string]any) map[string]any {
+	u := (&unstructured.Unstructured{Object: obj}).DeepCopy()
+	u.SetManagedFields(nil)
+	return u.Object
+}
+
+// plannedValues returns the planned_object and planned_changes attributes,
+// given the target objects before and after the patch, in the same order.
+// With a selector, planned_object maps the target key of each object to the
+// object and every change is prefixed with the target key.
+func plannedValues(data PatchResourceModel, before, after []*unstructured.Unstructured, ignore MetadataFilter) (types.String, types.List, error) {
+	var planned any
+	changes := []attr.Value{}
+
+	objects := make(map[string]any, len(after))
+	for i := range after {
+		from, err := normalizeJSON(withoutVolatileMetadata(before[i].Object))
+		if err != nil {
+			return types.StringNull(), types.ListNull(types.StringType), err
+		}
+		to, err := normalizeJSON(withoutVolatileMetadata(after[i].Object))
+		if err != nil {
+			return types.StringNull(), types.ListNull(types.StringType), err
+		}
+		from, to = ignore.strip(from), ignore.strip(to)
+
+		prefix := ""
+		if data.selectsMany() {
+			prefix = objectKey(after[i]) + ": "
+		}
+		for _, change := range describeChanges(from, to) {
+			changes = append(changes, types.StringValue(prefix+change))
+		}
+
+		objects[objectKey(after[i])] = to
+		planned = to
+	}
+	if data.selectsMany() {
+		planned = objects
+	}
+
+	b, err := json.Marshal(planned)
+	if err != nil {
+		return types.StringNull(), types.ListNull(types.StringType), err
+	}
+
+	return types.StringValue(string(b)), types.ListValueMust(types.StringType, changes), nil
+}
+
+// withoutVolatileMetadata returns a copy of obj without the metadata that
+// changes on every write.
+func withoutVolatileMetadata(obj map[string]any) map[string]any {
+	u := &unstructured.Unstructured{Object: withoutManagedFields(obj)}
+	u.SetResourceVersion("")
+	return u.Object
 }
 
-func (r *PatchResource) patch(ctx context.Context, data PatchResourceModel) error {
-	var pt k8stypes.PatchType
-	switch t := data.Type.ValueString(); t {
+// recordRevertData adds the current values of the paths touched by the patch
+// to the previously recorded entries.
+func recordRevertData(live map[string]any, patchType string, patch []byte, previous []revertEntry) ([]revertEntry, error) {
+	doc, err := normalizeJSON(live)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := touchedPaths(doc, patchType, patch)
+	if err != nil {
+		return nil, err
+	}
+
+	return captureRevert(doc, paths, previous)
+}
+
+func patchType(t string) k8stypes.PatchType {
+	switch t {
 	case "json":
-		pt = k8stypes.JSONPatchType
-	case "merge":
-		pt = k8stypes.MergePatchType
+		return k8stypes.JSONPatchType
 	case "strategic":
-		pt = k8stypes.StrategicMergePatchType
-	}
-
-	namespace := data.Namespace.ValueString()
-
-	var err error
-	switch res := data.Resource.ValueString(); res {
-	case "componentstatuses":
-		_, err = r.client.CoreV1().ComponentStatuses().Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "configmaps":
-		_, err = r.client.CoreV1().ConfigMaps(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "endpoints":
-		_, err = r.client.CoreV1().Endpoints(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "events":
-		_, err = r.client.CoreV1().Events(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "limitranges":
-		_, err = r.client.CoreV1().LimitRanges(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "namespaces":
-		_, err = r.client.CoreV1().Namespaces().Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "nodes":
-		_, err = r.client.CoreV1().Nodes().Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "persistentvolumeclaims":
-		_, err = r.client.CoreV1().PersistentVolumeClaims(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "persistentvolumes":
-		_, err = r.client.CoreV1().PersistentVolumes().Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "pods":
-		_, err = r.client.CoreV1().Pods(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "podtemplates":
-		_, err = r.client.CoreV1().PodTemplates(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "replicationcontrollers":
-		_, err = r.client.CoreV1().ReplicationControllers(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "resourcequotas":
-		_, err = r.client.CoreV1().ResourceQuotas(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "secrets":
-		_, err = r.client.CoreV1().Secrets(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "serviceaccounts":
-		_, err = r.client.CoreV1().ServiceAccounts(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "services":
-		_, err = r.client.CoreV1().Services(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "controllerrevisions":
-		_, err = r.client.AppsV1().ControllerRevisions(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "daemonsets":
-		_, err = r.client.AppsV1().DaemonSets(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "deployments":
-		_, err = r.client.AppsV1().Deployments(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "replicasets":
-		_, err = r.client.AppsV1().ReplicaSets(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "statefulsets":
-		_, err = r.client.AppsV1().StatefulSets(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "cronjobs":
-		_, err = r.client.BatchV1().CronJobs(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "jobs":
-		_, err = r.client.BatchV1().Jobs(namespace).Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
-	case "validatingwebhookconfigurations":
-		_, err = r.client.AdmissionregistrationV1().ValidatingWebhookConfigurations().Patch(ctx, data.Name.ValueString(), pt, []byte(data.Data.ValueString()), metav1.PatchOptions{})
+		return k8stypes.StrategicMergePatchType
+	case "apply":
+		return k8stypes.ApplyPatchType
+	default:
+		return k8stypes.MergePatchType
 	}
-
-	return err
 }
 
 func (r *PatchResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
@@ -261,43 +928,152 @@ func (r *PatchResource) Read(ctx context.Context, req resource.ReadRequest, resp
 		return
 	}
 
-	// If applicable, this is a great opportunity to initialize any necessary
-	// provider client data and make a call using it.
-	// httpResp, err := r.client.Do(httpReq)
-	// if err != nil {
-	//     resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read example, got error: %s", err))
-	//     return
-	// }
+	readTimeout, diags := data.Timeouts.Read(ctx, defaultTimeout)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, readTimeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, resp.Diagnostics, "read", readTimeout)
+	}()
+
+	ctx = r.withRetry(ctx, data)
+	resp.Diagnostics.Append(r.useCluster(data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	mapping, err := r.mapping(data)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read, got error: %s", err))
+		return
+	}
+
+	objects, err := r.matchingObjects(ctx, data, mapping)
+	if apierrors.IsNotFound(err) && !data.selectsMany() {
+		tflog.Warn(ctx, "patched object no longer exists, removing from state", map[string]any{"name": data.Name.ValueString()})
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read, got error: %s", err))
+		return
+	}
+
+	inEffect := true
+	keys := targetKeys(objects)
+	if data.selectsMany() {
+		// The targets stay those of the last apply, so that objects which
+		// no longer match can be released by the next one.
+		var previous []string
+		resp.Diagnostics.Append(data.Targets.ElementsAs(ctx, &previous, false)...)
+
+		if resp.Diagnostics.HasError() {
+			return
+		}
+
+		added, removed := targetChanges(previous, keys)
+		if len(added) > 0 || len(removed) > 0 {
+			tflog.Info(ctx, "objects matching the selector have changed", map[string]any{"added": added, "removed": removed})
+			inEffect = false
+		}
+	} else {
+		data.Targets = targetsValue(keys)
+	}
+
+	for _, live := range objects {
+		liveJSON, err := live.MarshalJSON()
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read, got error: %s", err))
+			return
+		}
+
+		body, err := data.Data.Normalize()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("data"), "Invalid Patch", err.Error())
+			return
+		}
+
+		objectInEffect, reason, err := patchInEffect(liveJSON, data.Type.ValueString(), body, objectKind(mapping, live), r.ignore)
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to check whether the patch is in effect on %s, got error: %s", objectKey(live), err))
+			return
+		}
+		if !objectInEffect {
+			tflog.Info(ctx, "patch is no longer in effect", map[string]any{"object": objectKey(live), "reason": reason})
+			inEffect = false
+		}
+	}
+	data.InEffect = types.BoolValue(inEffect)
+
+	err = setResult(ctx, &data, objects, r.ignore)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read, got error: %s", err))
+		return
+	}
+	data.ApiVersion = types.StringValue(mapping.GroupVersionKind.GroupVersion().String())
+	data.Id = types.StringValue(patchID(mapping, data.targetNamespace(), data.targetName()))
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
 
 func (r *PatchResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
-	var data PatchResourceModel
+	var data, state PatchResourceModel
 
 	// Read Terraform plan data into the model
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
 
 	if resp.Diagnostics.HasError() {
 		return
 	}
 
-	// If applicable, this is a great opportunity to initialize any necessary
-	// provider client data and make a call using it.
-	// httpResp, err := r.client.Do(httpReq)
-	// if err != nil {
-	//     resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update example, got error: %s", err))
-	//     return
-	// }
-	err := r.patch(ctx, data)
-	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to patch, got error: %s", err))
+	updateTimeout, diags := data.Timeouts.Update(ctx, defaultTimeout)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, updateTimeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, resp.Diagnostics, "update", updateTimeout)
+	}()
+
+	ctx = r.withRetry(ctx, data)
+	resp.Diagnostics.Append(r.useCluster(data)...)
+
+	var previous []string
+	resp.Diagnostics.Append(state.Targets.ElementsAs(ctx, &previous, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// Values recorded by earlier applies are the ones to restore on destroy.
+	data.RevertData = state.RevertData
+
+	resp.Diagnostics.Append(r.patch(ctx, &data, previous)...)
+
+	if resp.Diagnostics.HasError() {
 		return
 	}
+	data.InEffect = types.BoolValue(true)
 
 	// Save updated data into Terraform state
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+
+	err := r.wait(ctx, data)
+	if err != nil {
+		resp.Diagnostics.AddError("Wait Failed", fmt.Sprintf("The patch was applied, but the object did not reach the expected state: %s", err))
+	}
 }
 
 func (r *PatchResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -310,15 +1086,409 @@ func (r *PatchResource) Delete(ctx context.Context, req resource.DeleteRequest,
 		return
 	}
 
-	// If applicable, this is a great opportunity to initialize any necessary
-	// provider client data and make a call using it.
-	// httpResp, err := r.client.Do(httpReq)
-	// if err != nil {
-	//     resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete example, got error: %s", err))
-	//     return
-	// }
+	if data.DestroyBehavior.ValueString() == "none" {
+		return
+	}
+
+	deleteTimeout, diags := data.Timeouts.Delete(ctx, defaultTimeout)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deleteTimeout)
+	defer cancel()
+	defer func() {
+		resp.Diagnostics = timeoutDiagnostics(ctx, resp.Diagnostics, "delete", deleteTimeout)
+	}()
+
+	ctx = r.withRetry(ctx, data)
+
+	resp.Diagnostics.Append(r.useCluster(data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	mapping, err := r.mapping(data)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to release the patch, got error: %s", err))
+		return
+	}
+
+	revertData, err := decodeRevertData(data.RevertData)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to revert patch, got error: %s", err))
+		return
+	}
+
+	var keys []string
+	resp.Diagnostics.Append(data.Targets.ElementsAs(ctx, &keys, false)...)
+	if data.Targets.IsNull() && !data.selectsMany() {
+		// Imported or created by an earlier version of the provider.
+		keys = []string{namedTargetKey(data, mapping)}
+	}
+
+	for _, key := range keys {
+		resp.Diagnostics.Append(r.release(ctx, data, mapping, key, revertData)...)
+	}
+}
+
+// release undoes the patch on the object with the given target key according
+// to destroy_behavior. It is used on destroy, and for objects that no longer
+// match the selector.
+func (r *PatchResource) release(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping, key string, revertData map[string][]revertEntry) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	switch data.DestroyBehavior.ValueString() {
+	case "none":
+	case "custom":
+		body, err := data.DestroyData.Normalize()
+		if err == nil {
+			err = r.patchOnDestroy(ctx, data, mapping, key, body)
+		}
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to apply destroy_data to %s, got error: %s", key, err))
+		}
+	default:
+		if data.Type.ValueString() == "apply" {
+			// Applying an empty configuration releases ownership of every
+			// field, removing those no other manager owns.
+			err := r.patchOnDestroy(ctx, data, mapping, key, []byte("{}"))
+			if err != nil {
+				diags.AddError("Client Error", fmt.Sprintf("Unable to release field ownership of %s, got error: %s", key, err))
+			}
+			return diags
+		}
+
+		entries, ok := revertData[key]
+		if !ok {
+			diags.AddWarning(
+				"Patch not reverted",
+				fmt.Sprintf("No pre-patch values were recorded for %s, so the object has been left as it is. Re-apply the patch with this provider version to record them.", key),
+			)
+			return diags
+		}
+
+		err := r.revert(ctx, data, mapping, key, entries)
+		if err != nil {
+			diags.AddError("Client Error", fmt.Sprintf("Unable to revert patch on %s, got error: %s", key, err))
+		}
+	}
+
+	return diags
+}
+
+// patchOnDestroy applies body, of the resource's patch type, to the object
+// with the given target key if it still exists.
+func (r *PatchResource) patchOnDestroy(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping, key string, body []byte) error {
+	namespace, name := splitTargetKey(key)
+
+	client, err := r.client.ResourceInterface(mapping, namespace)
+	if err != nil {
+		return err
+	}
+
+	body, options, err := patchRequest(data, mapping, namespace, name, body)
+	if err != nil {
+		return err
+	}
+
+	_, err = client.Patch(ctx, name, patchType(data.Type.ValueString()), body, options, data.subresources()...)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// revert restores the values recorded in entries on the object with the
+// given target key.
+func (r *PatchResource) revert(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping, key string, entries []revertEntry) error {
+	namespace, name := splitTargetKey(key)
+
+	client, err := r.client.ResourceInterface(mapping, namespace)
+	if err != nil {
+		return err
+	}
+
+	live, err := client.Get(ctx, name, metav1.GetOptions{}, data.subresources()...)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	doc, err := normalizeJSON(live.Object)
+	if err != nil {
+		return err
+	}
+
+	ops, err := revertOperations(doc, entries)
+	if err != nil {
+		return err
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	patch, err := json.Marshal(ops)
+	if err != nil {
+		return err
+	}
+
+	tflog.Debug(ctx, "reverting patch", map[string]any{"object": key, "patch": string(patch)})
+
+	_, err = client.Patch(ctx, name, k8stypes.JSONPatchType, patch, metav1.PatchOptions{FieldManager: data.FieldManager.ValueString()}, data.subresources()...)
+	return err
+}
+
+func (r *PatchResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	// Nothing to do on destroy, or when nothing changes.
+	if req.Plan.Raw.IsNull() || req.Plan.Raw.Equal(req.State.Raw) {
+		return
+	}
+
+	var data PatchResourceModel
+
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	ctx = r.withRetry(ctx, data)
+
+	// Re-applying a patch that is no longer in effect changes the attributes
+	// describing the patched objects, which the framework only marks as
+	// unknown when the configuration changes.
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("revert_data"), types.StringUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("targets"), types.ListUnknown(types.StringType))...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("planned_object"), types.StringUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("planned_changes"), types.ListUnknown(types.StringType))...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("result"), types.StringUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("resource_version"), types.StringUnknown())...)
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("generation"), types.Int64Unknown())...)
+	}
+
+	// The dry-run needs to know which cluster to send the patch to.
+	if len(data.Cluster) > 0 && !data.Cluster[0].known() {
+		return
+	}
+	resp.Diagnostics.Append(r.useCluster(data)...)
+
+	if r.client == nil || resp.Diagnostics.HasError() {
+		return
+	}
+
+	// api_version is computed, so only a configured value can be unknown.
+	var apiVersion types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("api_version"), &apiVersion)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	// The dry-run needs every input of the patch request.
+	inputs := []attr.Value{data.Namespace, data.AllNamespaces, data.NamespaceSelector, apiVersion, data.Resource, data.Name, data.Subresource, data.Type, data.Data, data.FieldManager, data.Force}
+	for _, selector := range data.Selector {
+		inputs = append(inputs, selector.LabelSelector, selector.FieldSelector, selector.NameRegex)
+	}
+	for _, v := range inputs {
+		if v.IsUnknown() {
+			return
+		}
+	}
+
+	mapping, err := r.mapping(data)
+	if meta.IsNoMatchError(err) {
+		tflog.Debug(ctx, "skipping dry-run, the resource is not served yet", map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Dry-Run Failed", fmt.Sprintf("Unable to resolve the patched object, got error: %s", err))
+		return
+	}
+
+	err = r.checkSubresource(data, mapping)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("subresource"), "Invalid Subresource", err.Error())
+		return
+	}
+
+	objects, err := r.matchingObjects(ctx, data, mapping)
+	if apierrors.IsNotFound(err) && !data.selectsMany() {
+		tflog.Debug(ctx, "skipping dry-run, the object does not exist yet", map[string]any{"name": data.Name.ValueString()})
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Dry-Run Failed", fmt.Sprintf("Unable to read the patched object, got error: %s", err))
+		return
+	}
+
+	results := make([]*unstructured.Unstructured, 0, len(objects))
+	for _, live := range objects {
+		result, err := r.dryRun(ctx, data, mapping, live)
+		if isApplyConflict(err) {
+			resp.Diagnostics.AddError("Server-Side Apply Conflict", describeApplyConflict(err, live.GetManagedFields()))
+			return
+		}
+		if err != nil {
+			resp.Diagnostics.AddError("Dry-Run Failed", fmt.Sprintf("The API server rejected the patch of %s, got error: %s", objectKey(live), err))
+			return
+		}
+		results = append(results, result)
+	}
+
+	plannedObject, plannedChanges, err := plannedValues(data, objects, results, r.ignore)
+	if err != nil {
+		resp.Diagnostics.AddError("Dry-Run Failed", err.Error())
+		return
+	}
+
+	if data.ApiVersion.IsUnknown() {
+		resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("api_version"), mapping.GroupVersionKind.GroupVersion().String())...)
+	}
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("targets"), targetsValue(targetKeys(objects)))...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("planned_object"), plannedObject)...)
+	resp.Diagnostics.Append(resp.Plan.SetAttribute(ctx, path.Root("planned_changes"), plannedChanges)...)
+}
+
+// dryRun sends the patch to live as a server-side dry-run and returns the
+// object the API server would store.
+func (r *PatchResource) dryRun(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping, live *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	client, err := r.client.ResourceInterface(mapping, live.GetNamespace())
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := data.Data.Normalize()
+	if err != nil {
+		return nil, err
+	}
+
+	body, options, err := patchRequest(data, mapping, live.GetNamespace(), live.GetName(), body)
+	if err != nil {
+		return nil, err
+	}
+	options.DryRun = []string{metav1.DryRunAll}
+
+	return client.Patch(ctx, live.GetName(), patchType(data.Type.ValueString()), body, options, data.subresources()...)
+}
+
+func (r *PatchResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data PatchResourceModel
+
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if data.DestroyBehavior.ValueString() == "custom" && data.DestroyData.IsNull() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("destroy_data"),
+			"Missing destroy_data",
+			"destroy_data must be set when destroy_behavior is \"custom\".",
+		)
+	}
+
+	for _, attribute := range []struct {
+		name string
+		body PatchData
+	}{{"data", data.Data}, {"destroy_data", data.DestroyData}} {
+		if attribute.body.IsNull() || attribute.body.IsUnknown() {
+			continue
+		}
+		body, err := attribute.body.Normalize()
+		if err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(attribute.name), "Invalid Patch", err.Error())
+			continue
+		}
+		if data.Type.IsNull() || data.Type.IsUnknown() {
+			continue
+		}
+		if err := validatePatch(data.Type.ValueString(), body); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root(attribute.name), "Invalid Patch", err.Error())
+		}
+	}
+
+	if !data.Name.IsUnknown() && data.Name.IsNull() != data.selectsMany() {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("name"),
+			"Invalid Target",
+			"Exactly one of name or a selector block must be set.",
+		)
+	}
+
+	// all_namespaces = false leaves the namespace to the other attributes.
+	if data.AllNamespaces.ValueBool() {
+		for _, attribute := range []struct {
+			name  string
+			value types.String
+		}{{"namespace", data.Namespace}, {"namespace_selector", data.NamespaceSelector}} {
+			if !attribute.value.IsNull() {
+				resp.Diagnostics.AddAttributeError(
+					path.Root("all_namespaces"),
+					"Invalid Attribute Combination",
+					fmt.Sprintf("%s cannot be set when all_namespaces is true.", attribute.name),
+				)
+			}
+		}
+	}
+
+	if v := data.NamespaceSelector; !v.IsNull() && !v.IsUnknown() {
+		if _, err := labels.Parse(v.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("namespace_selector"), "Invalid Label Selector", err.Error())
+		}
+	}
+
+	for i, selector := range data.Selector {
+		resp.Diagnostics.Append(validateSelector(path.Root("selector").AtListIndex(i), selector)...)
+	}
+
+	for i, preconditions := range data.Preconditions {
+		resp.Diagnostics.Append(validatePreconditions(path.Root("preconditions").AtListIndex(i), preconditions, data.selectsMany())...)
+	}
+
+	for i, w := range data.Wait {
+		resp.Diagnostics.Append(validateWait(path.Root("wait").AtListIndex(i), w)...)
+	}
 }
 
 func (r *PatchResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+	apiVersion, resourceName, namespace, name, err := parsePatchID(req.ID)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			fmt.Sprintf("Expected import identifier with format: %s, with an empty namespace for cluster-scoped resources. Got: %q", patchIDFormat, req.ID),
+		)
+		return
+	}
+	if namespace == "*" || name == "*" {
+		resp.Diagnostics.AddError(
+			"Unexpected Import Identifier",
+			"Patches with a selector or spanning namespaces cannot be imported, since the selection cannot be recovered from the patched objects.",
+		)
+		return
+	}
+
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), req.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("api_version"), apiVersion)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("resource"), resourceName)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("name"), name)...)
+	if namespace != "" {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("namespace"), namespace)...)
+	}
+
+	// The patch applied to the object cannot be recovered from it, so the
+	// import starts from an empty merge patch and the next apply applies the
+	// configured one.
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("type"), "merge")...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("data"), "{}")...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("field_manager"), "kubepatch")...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("force"), false)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("destroy_behavior"), "revert")...)
 }
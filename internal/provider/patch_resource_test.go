@@ -7,15 +7,30 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"net/http"
 	"os"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-timeouts/resource/timeouts"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
 	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/plancheck"
 	"github.com/hashicorp/terraform-plugin-testing/statecheck"
 	"github.com/hashicorp/terraform-plugin-testing/terraform"
 	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	restclient "k8s.io/client-go/rest"
 )
@@ -48,6 +63,29 @@ func TestAccPatchResource(t *testing.T) {
 	resource.Test(t, resource.TestCase{
 		PreCheck:                 func() { testAccPreCheck(t) },
 		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			deployment, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "opentelemetry-operator-controller-manager", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			// The args are reverted to those of the fixture.
+			expectedArgs := []string{"--metrics-addr=127.0.0.1:8080", "--enable-leader-election", "--zap-log-level=info", "--zap-time-encoding=rfc3339nano", "--enable-nginx-instrumentation=true"}
+			if len(deployment.Spec.Template.Spec.Containers[0].Args) != len(expectedArgs) {
+				return fmt.Errorf("expected %d args, got %d", len(expectedArgs), len(deployment.Spec.Template.Spec.Containers[0].Args))
+			}
+			for i, arg := range deployment.Spec.Template.Spec.Containers[0].Args {
+				if arg != expectedArgs[i] {
+					return fmt.Errorf("expected arg %d to be %q, got %q", i, expectedArgs[i], arg)
+				}
+			}
+			return nil
+		},
 		Steps: []resource.TestStep{
 			// Create and Read testing
 			{
@@ -56,7 +94,7 @@ func TestAccPatchResource(t *testing.T) {
 					statecheck.ExpectKnownValue(
 						"kubepatch_patch.test",
 						tfjsonpath.New("id"),
-						knownvalue.StringExact("example-id"),
+						knownvalue.StringExact("apps/v1/deployments/default/opentelemetry-operator-controller-manager"),
 					),
 				},
 				Check: func(state *terraform.State) error {
@@ -85,11 +123,28 @@ func TestAccPatchResource(t *testing.T) {
 			// Update and Read testing
 			{
 				Config: testAccPatchResourceConfigUpdate(t),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectKnownValue(
+							"kubepatch_patch.test",
+							tfjsonpath.New("planned_object"),
+							knownvalue.NotNull(),
+						),
+						// The generation bump is listed first.
+						plancheck.ExpectKnownValue(
+							"kubepatch_patch.test",
+							tfjsonpath.New("planned_changes"),
+							knownvalue.ListPartial(map[int]knownvalue.Check{
+								1: knownvalue.StringExact(`+ /spec/template/spec/containers/0/args/6: "enable-dotnet-instrumentation=true"`),
+							}),
+						),
+					},
+				},
 				ConfigStateChecks: []statecheck.StateCheck{
 					statecheck.ExpectKnownValue(
 						"kubepatch_patch.test",
 						tfjsonpath.New("id"),
-						knownvalue.StringExact("example-id"),
+						knownvalue.StringExact("apps/v1/deployments/default/opentelemetry-operator-controller-manager"),
 					),
 				},
 				Check: func(state *terraform.State) error {
@@ -115,6 +170,41 @@ func TestAccPatchResource(t *testing.T) {
 					return nil
 				},
 			},
+			// ImportState testing
+			{
+				ResourceName:      "kubepatch_patch.test",
+				ImportState:       true,
+				ImportStateVerify: true,
+				// The patch itself cannot be recovered from the object.
+				ImportStateVerifyIgnore: []string{"type", "data", "revert_data", "planned_object", "planned_changes"},
+			},
+			// Drift is detected and the patch re-applied
+			{
+				PreConfig: func() {
+					clientset, err := getClientSet()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					_, err = clientset.AppsV1().Deployments("default").Patch(context.TODO(), "opentelemetry-operator-controller-manager", k8stypes.JSONPatchType, []byte(`[{"op": "replace", "path": "/spec/template/spec/containers/0/args", "value": ["--metrics-addr=127.0.0.1:8080"]}]`), metav1.PatchOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: testAccPatchResourceConfigUpdate(t),
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("kubepatch_patch.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("in_effect"),
+						knownvalue.Bool(true),
+					),
+				},
+			},
 			// Delete testing automatically occurs in TestCase
 		},
 	})
@@ -155,3 +245,1189 @@ resource "kubepatch_patch" "test" {
 }
 `
 }
+
+func TestAccPatchResourceClusterScoped(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			clusterRole, err := clientset.RbacV1().ClusterRoles().Get(context.TODO(), "view", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			if v, ok := clusterRole.Labels["kubepatch.halter.io/test"]; ok {
+				return fmt.Errorf("expected label to be removed, got %q", v)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  api_version = "rbac.authorization.k8s.io/v1"
+  resource    = "clusterroles"
+  name        = "view"
+  type        = "merge"
+  data = jsonencode({
+    metadata = {
+      labels = {
+        "kubepatch.halter.io/test" = "cluster-scoped"
+      }
+    }
+  })
+}
+`,
+				Check: func(state *terraform.State) error {
+					clientset, err := getClientSet()
+					if err != nil {
+						return err
+					}
+
+					clusterRole, err := clientset.RbacV1().ClusterRoles().Get(context.TODO(), "view", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+
+					if v := clusterRole.Labels["kubepatch.halter.io/test"]; v != "cluster-scoped" {
+						return fmt.Errorf("expected label to be %q, got %q", "cluster-scoped", v)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceApply(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			deployment, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "opentelemetry-operator-controller-manager", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+
+			if v, ok := deployment.Annotations["kubepatch.halter.io/test"]; ok {
+				return fmt.Errorf("expected annotation to be removed, got %q", v)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace     = "default"
+  resource      = "deployments"
+  name          = "opentelemetry-operator-controller-manager"
+  type          = "apply"
+  field_manager = "kubepatch-test"
+  data          = <<-EOT
+    metadata:
+      annotations:
+        kubepatch.halter.io/test: apply
+  EOT
+}
+`,
+				Check: func(state *terraform.State) error {
+					clientset, err := getClientSet()
+					if err != nil {
+						return err
+					}
+
+					deployment, err := clientset.AppsV1().Deployments("default").Get(context.TODO(), "opentelemetry-operator-controller-manager", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+
+					if v := deployment.Annotations["kubepatch.halter.io/test"]; v != "apply" {
+						return fmt.Errorf("expected annotation to be %q, got %q", "apply", v)
+					}
+					for _, entry := range deployment.ManagedFields {
+						if entry.Manager == "kubepatch-test" && entry.Operation == metav1.ManagedFieldsOperationApply {
+							return nil
+						}
+					}
+					return fmt.Errorf("expected kubepatch-test to own fields through server-side apply")
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceDryRun(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace = "default"
+  resource  = "deployments"
+  name      = "opentelemetry-operator-controller-manager"
+  type      = "json"
+  data = jsonencode([
+    {
+      op    = "replace"
+      path  = "/spec/template/spec/doesnotexist/0"
+      value = "x"
+    },
+  ])
+}
+`,
+				PlanOnly:    true,
+				ExpectError: regexp.MustCompile("Dry-Run Failed"),
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceResult(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace    = "default"
+  resource     = "deployments"
+  name         = "opentelemetry-operator-controller-manager"
+  type         = "merge"
+  data         = jsonencode({ metadata = { labels = { "kubepatch.halter.io/test" = "result" } } })
+  result_paths = [".metadata.labels.kubepatch\\.halter\\.io/test", ".spec.replicas"]
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("result"),
+						knownvalue.StringExact(`{".metadata.labels.kubepatch\\.halter\\.io/test":"result",".spec.replicas":1}`),
+					),
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("uid"),
+						knownvalue.NotNull(),
+					),
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("resource_version"),
+						knownvalue.NotNull(),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceWait(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace = "default"
+  resource  = "deployments"
+  name      = "opentelemetry-operator-controller-manager"
+  type      = "strategic"
+  data      = jsonencode({ spec = { template = { metadata = { annotations = { "kubepatch.halter.io/restarted" = "wait" } } } } })
+
+  wait {
+    rollout = true
+
+    condition {
+      type = "Available"
+    }
+  }
+
+  timeouts {
+    create = "5m"
+  }
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("in_effect"),
+						knownvalue.Bool(true),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceSelector(t *testing.T) {
+	createConfigMap := func(name string, labels map[string]string) {
+		clientset, err := getClientSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = clientset.CoreV1().ConfigMaps("default").Create(context.TODO(), &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace = "default"
+  resource  = "configmaps"
+  type      = "merge"
+  data      = jsonencode({ data = { patched = "true" } })
+
+  selector {
+    label_selector = "kubepatch.halter.io/test=selector"
+    name_regex     = "^kubepatch-selector-"
+  }
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			createConfigMap("kubepatch-selector-a", map[string]string{"kubepatch.halter.io/test": "selector"})
+			createConfigMap("kubepatch-selector-b", map[string]string{"kubepatch.halter.io/test": "selector"})
+			createConfigMap("kubepatch-unselected", map[string]string{"kubepatch.halter.io/test": "selector"})
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			for _, name := range []string{"kubepatch-selector-a", "kubepatch-selector-b", "kubepatch-selector-c", "kubepatch-unselected"} {
+				configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.TODO(), name, metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if v, ok := configMap.Data["patched"]; ok {
+					return fmt.Errorf("expected %s to be reverted, got %q", name, v)
+				}
+				if err := clientset.CoreV1().ConfigMaps("default").Delete(context.TODO(), name, metav1.DeleteOptions{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("targets"),
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.StringExact("default/kubepatch-selector-a"),
+							knownvalue.StringExact("default/kubepatch-selector-b"),
+						}),
+					),
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("id"),
+						knownvalue.StringExact("v1/configmaps/default/*"),
+					),
+				},
+			},
+			// A newly matching object is detected as drift
+			{
+				PreConfig: func() {
+					createConfigMap("kubepatch-selector-c", map[string]string{"kubepatch.halter.io/test": "selector"})
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("kubepatch_patch.test", plancheck.ResourceActionUpdate),
+						plancheck.ExpectKnownValue(
+							"kubepatch_patch.test",
+							tfjsonpath.New("targets"),
+							knownvalue.ListSizeExact(3),
+						),
+					},
+				},
+			},
+			// An object that stops matching is reverted
+			{
+				PreConfig: func() {
+					clientset, err := getClientSet()
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					_, err = clientset.CoreV1().ConfigMaps("default").Patch(context.TODO(), "kubepatch-selector-a", k8stypes.MergePatchType, []byte(`{"metadata": {"labels": {"kubepatch.halter.io/test": null}}}`), metav1.PatchOptions{})
+					if err != nil {
+						t.Fatal(err)
+					}
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("kubepatch_patch.test", plancheck.ResourceActionUpdate),
+					},
+				},
+				Check: func(state *terraform.State) error {
+					clientset, err := getClientSet()
+					if err != nil {
+						return err
+					}
+
+					configMap, err := clientset.CoreV1().ConfigMaps("default").Get(context.TODO(), "kubepatch-selector-a", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					if v, ok := configMap.Data["patched"]; ok {
+						return fmt.Errorf("expected kubepatch-selector-a to be reverted, got %q", v)
+					}
+					return nil
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceNamespaceSelector(t *testing.T) {
+	createTenant := func(namespace string) {
+		clientset, err := getClientSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = clientset.CoreV1().Namespaces().Create(context.TODO(), &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: namespace, Labels: map[string]string{"kubepatch.halter.io/tenant": "true"}},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The default ServiceAccount is created asynchronously, so the test
+		// patches one of its own.
+		_, err = clientset.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), &corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubepatch-tenant"},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace_selector = "kubepatch.halter.io/tenant=true"
+  resource           = "serviceaccounts"
+  name               = "kubepatch-tenant"
+  type               = "merge"
+  data               = jsonencode({ imagePullSecrets = [{ name = "registry" }] })
+}
+`
+
+	resource.Test(t, resource.TestCase{
+		PreCheck: func() {
+			testAccPreCheck(t)
+			createTenant("kubepatch-tenant-a")
+			createTenant("kubepatch-tenant-b")
+		},
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			for _, namespace := range []string{"kubepatch-tenant-a", "kubepatch-tenant-b", "kubepatch-tenant-c"} {
+				serviceAccount, err := clientset.CoreV1().ServiceAccounts(namespace).Get(context.TODO(), "kubepatch-tenant", metav1.GetOptions{})
+				if err != nil {
+					return err
+				}
+				if len(serviceAccount.ImagePullSecrets) != 0 {
+					return fmt.Errorf("expected imagePullSecrets of %s to be reverted, got %v", namespace, serviceAccount.ImagePullSecrets)
+				}
+				if err := clientset.CoreV1().Namespaces().Delete(context.TODO(), namespace, metav1.DeleteOptions{}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: config,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("targets"),
+						knownvalue.ListExact([]knownvalue.Check{
+							knownvalue.StringExact("kubepatch-tenant-a/kubepatch-tenant"),
+							knownvalue.StringExact("kubepatch-tenant-b/kubepatch-tenant"),
+						}),
+					),
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("id"),
+						knownvalue.StringExact("v1/serviceaccounts/*/kubepatch-tenant"),
+					),
+				},
+			},
+			// A new tenant namespace shows up as a pending change
+			{
+				PreConfig: func() {
+					createTenant("kubepatch-tenant-c")
+				},
+				Config: config,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectResourceAction("kubepatch_patch.test", plancheck.ResourceActionUpdate),
+						plancheck.ExpectKnownValue(
+							"kubepatch_patch.test",
+							tfjsonpath.New("targets"),
+							knownvalue.ListSizeExact(3),
+						),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceSubresource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		CheckDestroy: func(state *terraform.State) error {
+			clientset, err := getClientSet()
+			if err != nil {
+				return err
+			}
+
+			scale, err := clientset.AppsV1().Deployments("default").GetScale(context.TODO(), "opentelemetry-operator-controller-manager", metav1.GetOptions{})
+			if err != nil {
+				return err
+			}
+			if scale.Spec.Replicas != 1 {
+				return fmt.Errorf("expected replicas to be reverted to 1, got %d", scale.Spec.Replicas)
+			}
+			return nil
+		},
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace   = "default"
+  resource    = "deployments"
+  name        = "opentelemetry-operator-controller-manager"
+  subresource = "scale"
+  type        = "merge"
+  data        = jsonencode({ spec = { replicas = 2 } })
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("in_effect"),
+						knownvalue.Bool(true),
+					),
+				},
+				Check: func(state *terraform.State) error {
+					clientset, err := getClientSet()
+					if err != nil {
+						return err
+					}
+
+					scale, err := clientset.AppsV1().Deployments("default").GetScale(context.TODO(), "opentelemetry-operator-controller-manager", metav1.GetOptions{})
+					if err != nil {
+						return err
+					}
+					if scale.Spec.Replicas != 2 {
+						return fmt.Errorf("expected 2 replicas, got %d", scale.Spec.Replicas)
+					}
+					return nil
+				},
+			},
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace   = "default"
+  resource    = "deployments"
+  name        = "opentelemetry-operator-controller-manager"
+  subresource = "logs"
+  type        = "merge"
+  data        = jsonencode({ spec = { replicas = 2 } })
+}
+`,
+				ExpectError: regexp.MustCompile(`no patchable subresource "logs"`),
+			},
+		},
+	})
+}
+
+func TestAccPatchResourceYAML(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace = "default"
+  resource  = "deployments"
+  name      = "opentelemetry-operator-controller-manager"
+  type      = "merge"
+  data      = <<-EOT
+    metadata:
+      labels:
+        kubepatch.halter.io/test: yaml
+      annotations:
+        kubepatch.halter.io/test: yaml
+  EOT
+}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("in_effect"),
+						knownvalue.Bool(true),
+					),
+				},
+			},
+			// The same patch as JSON with a different key order is not a change
+			{
+				Config: providerConfig(t) + `
+resource "kubepatch_patch" "test" {
+  namespace = "default"
+  resource  = "deployments"
+  name      = "opentelemetry-operator-controller-manager"
+  type      = "merge"
+  data = jsonencode({
+    metadata = {
+      annotations = { "kubepatch.halter.io/test" = "yaml" }
+      labels      = { "kubepatch.halter.io/test" = "yaml" }
+    }
+  })
+}
+`,
+				ConfigPlanChecks: resource.ConfigPlanChecks{
+					PreApply: []plancheck.PlanCheck{
+						plancheck.ExpectEmptyPlan(),
+					},
+				},
+			},
+		},
+	})
+}
+
+func TestAccPatchResourcePreconditions(t *testing.T) {
+	config := func(container string) string {
+		return providerConfig(t) + fmt.Sprintf(`
+resource "kubepatch_patch" "test" {
+  namespace = "default"
+  resource  = "deployments"
+  name      = "opentelemetry-operator-controller-manager"
+  type      = "json"
+  data      = jsonencode([{ op = "replace", path = "/spec/template/spec/containers/0/imagePullPolicy", value = "IfNotPresent" }])
+
+  preconditions {
+    field {
+      path  = ".spec.template.spec.containers[0].name"
+      value = %q
+    }
+  }
+}
+`, container)
+	}
+
+	resource.Test(t, resource.TestCase{
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactories,
+		Steps: []resource.TestStep{
+			{
+				Config:      config("not-the-first-container"),
+				ExpectError: regexp.MustCompile("Precondition Failed"),
+			},
+			{
+				Config: config("manager"),
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"kubepatch_patch.test",
+						tfjsonpath.New("in_effect"),
+						knownvalue.Bool(true),
+					),
+				},
+			},
+		},
+	})
+}
+
+// testDeployment returns a deployment with an app and a sidecar container.
+func testDeployment(namespace, name string, replicas int64) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]any{"namespace": namespace, "name": name},
+		"spec": map[string]any{
+			"replicas": replicas,
+			"template": map[string]any{
+				"spec": map[string]any{
+					"containers": []any{
+						map[string]any{"name": "app", "image": "app:1"},
+						map[string]any{"name": "sidecar", "image": "sidecar:1"},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// testConfigMap returns a config map with labels and data.
+func testConfigMap(namespace, name string, labels map[string]any, data map[string]any) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]any{"namespace": namespace, "name": name, "labels": labels},
+		"data":       data,
+	}}
+}
+
+var (
+	deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	configMapGVK  = schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+)
+
+func containerImages(t *testing.T, obj *unstructured.Unstructured) map[string]string {
+	t.Helper()
+
+	containers, _, err := unstructured.NestedSlice(obj.Object, "spec", "template", "spec", "containers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := map[string]string{}
+	for _, c := range containers {
+		c := c.(map[string]any)
+		images[c["name"].(string)], _ = c["image"].(string)
+	}
+	return images
+}
+
+func TestPatchResourceTypes(t *testing.T) {
+	for _, tc := range []struct {
+		patchType string
+		data      string
+		// replaced is whether the patch replaces the containers list rather
+		// than merging into it.
+		replaced bool
+	}{
+		{"json", `[{"op": "replace", "path": "/spec/template/spec/containers/0/image", "value": "app:2"}]`, false},
+		{"merge", `{"spec": {"template": {"spec": {"containers": [{"name": "app", "image": "app:2"}]}}}}`, true},
+		{"strategic", `{"spec": {"template": {"spec": {"containers": [{"name": "app", "image": "app:2"}]}}}}`, false},
+	} {
+		t.Run(tc.patchType, func(t *testing.T) {
+			server := newFakeAPIServer(t, testDeployment("default", "web", 1))
+			h := newResourceHarness(t, NewPatchResource(), server)
+			config := &PatchResourceModel{
+				Namespace:  types.StringValue("default"),
+				ApiVersion: types.StringValue("apps/v1"),
+				Resource:   types.StringValue("deployments"),
+				Name:       types.StringValue("web"),
+				Type:       types.StringValue(tc.patchType),
+				Data:       NewPatchDataValue(tc.data),
+			}
+
+			h.apply(config)
+
+			images := containerImages(t, server.get(deploymentGVK, "default", "web"))
+			if images["app"] != "app:2" {
+				t.Errorf("expected the app image to be patched, got %v", images)
+			}
+			if _, ok := images["sidecar"]; ok == tc.replaced {
+				t.Errorf("unexpected containers after a %s patch: %v", tc.patchType, images)
+			}
+
+			var state PatchResourceModel
+			h.get(&state)
+			if state.Id.ValueString() != "apps/v1/deployments/default/web" || !state.InEffect.ValueBool() {
+				t.Errorf("unexpected state: id %s, in_effect %s", state.Id, state.InEffect)
+			}
+
+			h.refresh()
+			if h.planChanges(config) {
+				t.Error("expected no changes after a refresh")
+			}
+
+			h.destroy()
+			images = containerImages(t, server.get(deploymentGVK, "default", "web"))
+			if images["app"] != "app:1" {
+				t.Errorf("expected the app image to be reverted, got %v", images)
+			}
+		})
+	}
+}
+
+func TestPatchResourceApply(t *testing.T) {
+	server := newFakeAPIServer(t, testDeployment("default", "web", 1))
+	h := newResourceHarness(t, NewPatchResource(), server)
+	config := &PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("apps/v1"),
+		Resource:   types.StringValue("deployments"),
+		Name:       types.StringValue("web"),
+		Type:       types.StringValue("apply"),
+		Data:       NewPatchDataValue(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"namespace": "default", "name": "web", "annotations": {"team": "platform"}}}`),
+	}
+
+	h.apply(config)
+	if annotations := server.get(deploymentGVK, "default", "web").GetAnnotations(); annotations["team"] != "platform" {
+		t.Errorf("expected the deployment to be annotated, got %v", annotations)
+	}
+	if patches := server.patches(); len(patches) == 0 || !strings.Contains(patches[len(patches)-1], "fieldManager=kubepatch") {
+		t.Errorf("expected the kubepatch field manager to apply the patch, got %v", patches)
+	}
+
+	h.refresh()
+	if h.planChanges(config) {
+		t.Error("expected no changes after a refresh")
+	}
+
+	// Releasing the applied fields removes those no other manager owns.
+	h.destroy()
+	if annotations := server.get(deploymentGVK, "default", "web").GetAnnotations(); annotations["team"] != "" {
+		t.Errorf("expected the annotation to be released, got %v", annotations)
+	}
+	if images := containerImages(t, server.get(deploymentGVK, "default", "web")); images["app"] != "app:1" {
+		t.Errorf("expected fields the patch did not apply to be kept, got %v", images)
+	}
+}
+
+func TestPatchResourceStrategicUnsupported(t *testing.T) {
+	widget := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"namespace": "default", "name": "gear"},
+		"spec":       map[string]any{"size": int64(1)},
+	}}
+	server := newFakeAPIServer(t, widget)
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	_, diags := h.tryApply(&PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("example.com/v1"),
+		Resource:   types.StringValue("widgets"),
+		Name:       types.StringValue("gear"),
+		Type:       types.StringValue("strategic"),
+		Data:       NewPatchDataValue(`{"spec": {"size": 2}}`),
+	})
+	if !diags.HasError() {
+		t.Fatal("expected a strategic merge patch of a custom resource to fail")
+	}
+	if size, _, _ := unstructured.NestedInt64(server.get(widget.GroupVersionKind(), "default", "gear").Object, "spec", "size"); size != 1 {
+		t.Errorf("expected the widget to be untouched, got size %d", size)
+	}
+}
+
+func TestPatchResourceSubresource(t *testing.T) {
+	server := newFakeAPIServer(t, testDeployment("default", "web", 1))
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	h.apply(&PatchResourceModel{
+		Namespace:   types.StringValue("default"),
+		ApiVersion:  types.StringValue("apps/v1"),
+		Resource:    types.StringValue("deployments"),
+		Name:        types.StringValue("web"),
+		Subresource: types.StringValue("scale"),
+		Type:        types.StringValue("merge"),
+		Data:        NewPatchDataValue(`{"spec": {"replicas": 3}}`),
+	})
+	if replicas, _, _ := unstructured.NestedInt64(server.get(deploymentGVK, "default", "web").Object, "spec", "replicas"); replicas != 3 {
+		t.Errorf("expected 3 replicas, got %d", replicas)
+	}
+	if patches := server.patches(); len(patches) == 0 || !strings.Contains(patches[len(patches)-1], "/deployments/web/scale") {
+		t.Errorf("expected the scale subresource to be patched, got %v", patches)
+	}
+
+	h.destroy()
+	if replicas, _, _ := unstructured.NestedInt64(server.get(deploymentGVK, "default", "web").Object, "spec", "replicas"); replicas != 1 {
+		t.Errorf("expected the replicas to be reverted, got %d", replicas)
+	}
+}
+
+func TestPatchResourceSelector(t *testing.T) {
+	selected := map[string]any{"patch": "true"}
+	server := newFakeAPIServer(t,
+		testConfigMap("a", "one", selected, map[string]any{"key": "one"}),
+		testConfigMap("b", "two", selected, map[string]any{"key": "two"}),
+		testConfigMap("b", "other", nil, map[string]any{"key": "other"}),
+	)
+	h := newResourceHarness(t, NewPatchResource(), server)
+	config := &PatchResourceModel{
+		AllNamespaces: types.BoolValue(true),
+		ApiVersion:    types.StringValue("v1"),
+		Resource:      types.StringValue("configmaps"),
+		Selector:      []PatchSelectorModel{{LabelSelector: types.StringValue("patch=true"), FieldSelector: types.StringNull(), NameRegex: types.StringNull()}},
+		Type:          types.StringValue("merge"),
+		Data:          NewPatchDataValue(`{"data": {"key": "patched"}}`),
+	}
+
+	h.apply(config)
+
+	var state PatchResourceModel
+	h.get(&state)
+	var targets []string
+	state.Targets.ElementsAs(context.Background(), &targets, false)
+	if fmt.Sprint(targets) != "[a/one b/two]" {
+		t.Errorf("unexpected targets %v", targets)
+	}
+
+	server.add(t, testConfigMap("c", "three", selected, map[string]any{"key": "three"}))
+	h.refresh()
+	h.get(&state)
+	if state.InEffect.ValueBool() {
+		t.Error("expected a newly selected object to put the patch out of effect")
+	}
+	if !h.apply(config) {
+		t.Error("expected the patch to be applied to the newly selected object")
+	}
+
+	for _, key := range []string{"a/one", "b/two", "c/three"} {
+		namespace, name := splitTargetKey(key)
+		if v, _, _ := unstructured.NestedString(server.get(configMapGVK, namespace, name).Object, "data", "key"); v != "patched" {
+			t.Errorf("expected %s to be patched, got %q", key, v)
+		}
+	}
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "b", "other").Object, "data", "key"); v != "other" {
+		t.Errorf("expected an unselected object to be untouched, got %q", v)
+	}
+
+	h.destroy()
+	for _, key := range []string{"a/one", "b/two", "c/three"} {
+		namespace, name := splitTargetKey(key)
+		if v, _, _ := unstructured.NestedString(server.get(configMapGVK, namespace, name).Object, "data", "key"); v != name {
+			t.Errorf("expected %s to be reverted, got %q", key, v)
+		}
+	}
+}
+
+func TestPatchResourceClusterScoped(t *testing.T) {
+	role := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "rbac.authorization.k8s.io/v1",
+		"kind":       "ClusterRole",
+		"metadata":   map[string]any{"name": "viewer"},
+		"rules":      []any{},
+	}}
+	server := newFakeAPIServer(t, role)
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	h.apply(&PatchResourceModel{
+		ApiVersion: types.StringValue("rbac.authorization.k8s.io/v1"),
+		Resource:   types.StringValue("clusterroles"),
+		Name:       types.StringValue("viewer"),
+		Type:       types.StringValue("json"),
+		Data:       NewPatchDataValue(`[{"op": "add", "path": "/metadata/labels", "value": {"team": "platform"}}]`),
+	})
+	if labels := server.get(role.GroupVersionKind(), "", "viewer").GetLabels(); labels["team"] != "platform" {
+		t.Errorf("expected the cluster role to be labelled, got %v", labels)
+	}
+
+	var state PatchResourceModel
+	h.get(&state)
+	if state.Id.ValueString() != "rbac.authorization.k8s.io/v1/clusterroles//viewer" {
+		t.Errorf("unexpected id %s", state.Id)
+	}
+}
+
+func TestPatchResourceDrift(t *testing.T) {
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	h := newResourceHarness(t, NewPatchResource(), server)
+	config := &PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+	}
+
+	h.apply(config)
+	server.add(t, testConfigMap("default", "settings", nil, map[string]any{"key": "changed"}))
+
+	h.refresh()
+	var state PatchResourceModel
+	h.get(&state)
+	if state.InEffect.ValueBool() {
+		t.Fatal("expected an out-of-band change to put the patch out of effect")
+	}
+	if !h.apply(config) {
+		t.Fatal("expected the patch to be applied again")
+	}
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key"); v != "patched" {
+		t.Errorf("expected the patch to be in effect again, got %q", v)
+	}
+
+	h.refresh()
+	if h.planChanges(config) {
+		t.Error("expected no changes after the patch was applied again")
+	}
+}
+
+func TestPatchResourcePreconditionFailed(t *testing.T) {
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	_, diags := h.tryApply(&PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+		Preconditions: []PatchPreconditionsModel{{
+			ResourceVersion: types.StringNull(),
+			Uid:             types.StringNull(),
+			Field:           []PatchPreconditionFieldModel{{Path: types.StringValue("{.data.key}"), Value: types.StringValue("expected")}},
+		}},
+	})
+	if !diags.HasError() || diags[0].Summary() != "Precondition Failed" {
+		t.Fatalf("expected a precondition failure, got %v", diags)
+	}
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key"); v != "original" {
+		t.Errorf("expected the object to be untouched, got %q", v)
+	}
+}
+
+func TestPatchResourceApplyConflict(t *testing.T) {
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	config := func(manager string, force bool) *PatchResourceModel {
+		return &PatchResourceModel{
+			Namespace:    types.StringValue("default"),
+			ApiVersion:   types.StringValue("v1"),
+			Resource:     types.StringValue("configmaps"),
+			Name:         types.StringValue("settings"),
+			Type:         types.StringValue("apply"),
+			Data:         NewPatchDataValue(fmt.Sprintf(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"namespace": "default", "name": "settings"}, "data": {"key": %q}}`, manager)),
+			FieldManager: types.StringValue(manager),
+			Force:        types.BoolValue(force),
+		}
+	}
+
+	newResourceHarness(t, NewPatchResource(), server).apply(config("first", false))
+
+	second := newResourceHarness(t, NewPatchResource(), server)
+	if _, diags := second.tryApply(config("second", false)); !diags.HasError() {
+		t.Fatal("expected applying a field owned by another manager to conflict")
+	}
+	second.apply(config("second", true))
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key"); v != "second" {
+		t.Errorf("expected a forced apply to take the field over, got %q", v)
+	}
+}
+
+func TestPatchResourceRetry(t *testing.T) {
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	server.fail(http.StatusServiceUnavailable, http.StatusServiceUnavailable)
+	h.apply(&PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+	})
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key"); v != "patched" {
+		t.Errorf("expected the patch to be applied after transient errors, got %q", v)
+	}
+}
+
+func TestPatchResourceConnectionLost(t *testing.T) {
+	server := newFakeAPIServer(t, testDeployment("default", "app", 1))
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	// A JSON patch appending to a list is not sent again once the server may
+	// have applied it.
+	server.dropPatches(1)
+	_, diags := h.tryApply(&PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("apps/v1"),
+		Resource:   types.StringValue("deployments"),
+		Name:       types.StringValue("app"),
+		Type:       types.StringValue("json"),
+		Data:       NewPatchDataValue(`[{"op": "add", "path": "/spec/template/spec/containers/-", "value": {"name": "proxy", "image": "proxy:1"}}]`),
+	})
+	if !diags.HasError() {
+		t.Fatal("expected the lost connection to be reported")
+	}
+	containers, _, _ := unstructured.NestedSlice(server.get(deploymentGVK, "default", "app").Object, "spec", "template", "spec", "containers")
+	if len(containers) != 3 {
+		t.Errorf("expected the container to be appended once, got %d containers", len(containers))
+	}
+
+	// Server-side apply is idempotent and retried.
+	server.dropPatches(1)
+	h = newResourceHarness(t, NewPatchResource(), server)
+	h.apply(&PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("apps/v1"),
+		Resource:   types.StringValue("deployments"),
+		Name:       types.StringValue("app"),
+		Type:       types.StringValue("apply"),
+		Data:       NewPatchDataValue(`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "app", "namespace": "default", "annotations": {"team": "payments"}}}`),
+	})
+	if v := server.get(deploymentGVK, "default", "app").GetAnnotations()["team"]; v != "payments" {
+		t.Errorf("expected the apply to be retried, got annotation %q", v)
+	}
+}
+
+func TestPatchResourceTimeout(t *testing.T) {
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	timeoutTypes := map[string]attr.Type{"create": types.StringType, "read": types.StringType, "update": types.StringType, "delete": types.StringType}
+	config := &PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+		Timeouts: timeouts.Value{Object: types.ObjectValueMust(timeoutTypes, map[string]attr.Value{
+			"create": types.StringValue("200ms"),
+			"read":   types.StringNull(),
+			"update": types.StringNull(),
+			"delete": types.StringNull(),
+		})},
+	}
+
+	server.delayPatches(time.Minute)
+	start := time.Now()
+	_, diags := h.tryApply(config)
+	if !diags.HasError() || diags[0].Summary() != "Timeout" {
+		t.Fatalf("expected a timeout, got %v", diags)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("expected the create timeout to bound the patch, returned after %s", elapsed)
+	}
+	if v, _, _ := unstructured.NestedString(server.get(configMapGVK, "default", "settings").Object, "data", "key"); v != "original" {
+		t.Errorf("expected the object to be untouched, got %q", v)
+	}
+
+	// Errors of the API server within the timeout are reported as such.
+	server.delayPatches(0)
+	server.fail(http.StatusForbidden)
+	if _, diags := h.tryApply(config); !diags.HasError() || diags[0].Summary() == "Timeout" {
+		t.Fatalf("expected an API error, got %v", diags)
+	}
+}
+
+func TestPatchResourceValidatePatch(t *testing.T) {
+	server := newFakeAPIServer(t, testConfigMap("default", "settings", nil, map[string]any{"key": "original"}))
+	h := newResourceHarness(t, NewPatchResource(), server)
+
+	_, diags := h.plan(&PatchResourceModel{
+		Namespace:       types.StringValue("default"),
+		ApiVersion:      types.StringValue("v1"),
+		Resource:        types.StringValue("configmaps"),
+		Name:            types.StringValue("settings"),
+		Type:            types.StringValue("json"),
+		Data:            NewPatchDataValue(`[{"op": "move", "path": "/data/other"}]`),
+		DestroyBehavior: types.StringValue("custom"),
+		DestroyData:     NewPatchDataValue(`{"data": {"key": "original"}}`),
+	})
+	if len(diags) != 2 || diags[0].Summary() != "Invalid Patch" || diags[1].Summary() != "Invalid Patch" {
+		t.Fatalf("expected data and destroy_data to be rejected during plan, got %v", diags)
+	}
+	if len(server.patches()) != 0 {
+		t.Errorf("expected no request to be sent, got %v", server.patches())
+	}
+
+	// Wait conditions are validated before the object is patched.
+	_, diags = h.plan(&PatchResourceModel{
+		Namespace:  types.StringValue("default"),
+		ApiVersion: types.StringValue("v1"),
+		Resource:   types.StringValue("configmaps"),
+		Name:       types.StringValue("settings"),
+		Type:       types.StringValue("merge"),
+		Data:       NewPatchDataValue(`{"data": {"key": "patched"}}`),
+		Wait: []PatchWaitModel{{
+			Field: []PatchWaitFieldModel{{Path: types.StringValue(".data[key"), Value: types.StringValue("patched")}},
+		}},
+	})
+	if len(diags) != 1 || diags[0].Summary() != "Invalid JSONPath" {
+		t.Fatalf("expected the wait path to be rejected during plan, got %v", diags)
+	}
+	if len(server.patches()) != 0 {
+		t.Errorf("expected no request to be sent, got %v", server.patches())
+	}
+}
+
+func TestPatchResourceValidateAllNamespaces(t *testing.T) {
+	ctx := context.Background()
+	server, err := testAccProtoV6ProviderFactories["kubepatch"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schemaResp, err := server.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectType := schemaResp.ResourceSchemas["kubepatch_patch"].ValueType().(tftypes.Object)
+
+	for _, tc := range []struct {
+		attribute     string
+		allNamespaces bool
+		valid         bool
+	}{
+		{"namespace", false, true},
+		{"namespace", true, false},
+		{"namespace_selector", false, true},
+		{"namespace_selector", true, false},
+	} {
+		t.Run(fmt.Sprintf("%s all_namespaces=%t", tc.attribute, tc.allNamespaces), func(t *testing.T) {
+			values := map[string]tftypes.Value{}
+			for name, typ := range objectType.AttributeTypes {
+				values[name] = tftypes.NewValue(typ, nil)
+			}
+			values["resource"] = tftypes.NewValue(tftypes.String, "deployments")
+			if tc.attribute == "namespace" && !tc.allNamespaces {
+				values["name"] = tftypes.NewValue(tftypes.String, "app")
+			}
+			values["type"] = tftypes.NewValue(tftypes.String, "merge")
+			values["data"] = tftypes.NewValue(tftypes.String, `{"spec": {}}`)
+			values["all_namespaces"] = tftypes.NewValue(tftypes.Bool, tc.allNamespaces)
+			values[tc.attribute] = tftypes.NewValue(tftypes.String, "team=payments")
+
+			config, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, values))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := server.ValidateResourceConfig(ctx, &tfprotov6.ValidateResourceConfigRequest{TypeName: "kubepatch_patch", Config: &config})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var summaries []string
+			for _, d := range resp.Diagnostics {
+				summaries = append(summaries, d.Summary)
+			}
+			expected := []string{"Invalid Attribute Combination"}
+			if tc.valid {
+				expected = nil
+			}
+			if !reflect.DeepEqual(summaries, expected) {
+				t.Errorf("expected diagnostics %v, got %v", expected, summaries)
+			}
+		})
+	}
+}
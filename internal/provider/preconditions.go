@@ -0,0 +1,140 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// PatchPreconditionsModel describes what the target object must look like for
+// the patch to be applied.
+type PatchPreconditionsModel struct {
+	ResourceVersion types.String                  `tfsdk:"resource_version"`
+	Uid             types.String                  `tfsdk:"uid"`
+	Field           []PatchPreconditionFieldModel `tfsdk:"field"`
+}
+
+// PatchPreconditionFieldModel requires a JSONPath expression to evaluate to a
+// value.
+type PatchPreconditionFieldModel struct {
+	Path  types.String `tfsdk:"path"`
+	Value types.String `tfsdk:"value"`
+}
+
+// preconditionError is returned when a target object does not meet the
+// preconditions of the patch.
+type preconditionError struct {
+	key     string
+	message string
+}
+
+func (e *preconditionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.key, e.message)
+}
+
+// checkPreconditions returns a preconditionError describing the first
+// precondition of p that obj does not meet, if any.
+func checkPreconditions(obj *unstructured.Unstructured, p PatchPreconditionsModel) error {
+	unmet := func(format string, args ...any) error {
+		return &preconditionError{key: objectKey(obj), message: fmt.Sprintf(format, args...)}
+	}
+
+	if !p.Uid.IsNull() && string(obj.GetUID()) != p.Uid.ValueString() {
+		return unmet("uid is %q, expected %q", obj.GetUID(), p.Uid.ValueString())
+	}
+	if !p.ResourceVersion.IsNull() && obj.GetResourceVersion() != p.ResourceVersion.ValueString() {
+		return unmet("resource version is %q, expected %q", obj.GetResourceVersion(), p.ResourceVersion.ValueString())
+	}
+
+	for _, f := range p.Field {
+		value, err := evaluateJSONPath(obj.Object, f.Path.ValueString())
+		if err != nil {
+			return err
+		}
+		if actual := formatValue(value); actual != f.Value.ValueString() {
+			return unmet("%s is %q, expected %q", f.Path.ValueString(), actual, f.Value.ValueString())
+		}
+	}
+	return nil
+}
+
+// validatePreconditions reports preconditions that can never be met, at p.
+// Resource versions and UIDs identify a single object, so they require the
+// patch to target one by name.
+func validatePreconditions(p path.Path, preconditions PatchPreconditionsModel, selectsMany bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if selectsMany {
+		for _, attribute := range []struct {
+			name  string
+			value types.String
+		}{
+			{"resource_version", preconditions.ResourceVersion},
+			{"uid", preconditions.Uid},
+		} {
+			if !attribute.value.IsNull() {
+				diags.AddAttributeError(p.AtName(attribute.name), "Invalid Precondition", fmt.Sprintf("%s can only be required of a patch targeting an object by name.", attribute.name))
+			}
+		}
+	}
+
+	for i, f := range preconditions.Field {
+		if f.Path.IsUnknown() {
+			continue
+		}
+		if _, err := parseJSONPath(f.Path.ValueString()); err != nil {
+			diags.AddAttributeError(p.AtName("field").AtListIndex(i).AtName("path"), "Invalid JSONPath", err.Error())
+		}
+	}
+	return diags
+}
+
+// pinResourceVersion adds resourceVersion to a patch body, so that the API
+// server rejects the patch with a conflict if the object was modified after
+// its preconditions were checked.
+func pinResourceVersion(patchType string, body []byte, resourceVersion string) ([]byte, error) {
+	if patchType == "json" {
+		var operations []any
+		if err := decodeJSON(body, &operations); err != nil {
+			return nil, err
+		}
+		test := map[string]any{"op": "test", "path": "/metadata/resourceVersion", "value": resourceVersion}
+		return json.Marshal(append([]any{test}, operations...))
+	}
+
+	var patch map[string]any
+	if err := decodeJSON(body, &patch); err != nil {
+		return nil, err
+	}
+	metadata, _ := patch["metadata"].(map[string]any)
+	if metadata == nil {
+		metadata = map[string]any{}
+	}
+	metadata["resourceVersion"] = resourceVersion
+	patch["metadata"] = metadata
+	return json.Marshal(patch)
+}
+
+// decodeJSON decodes b into v, keeping numbers as written. Like
+// json.Unmarshal, it rejects data after the value.
+func decodeJSON(b []byte, v any) error {
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCheckPreconditions(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"metadata": map[string]any{
+			"name":            "web",
+			"namespace":       "default",
+			"uid":             "8b6f0c3e",
+			"resourceVersion": "42",
+		},
+		"spec": map[string]any{
+			"replicas": int64(2),
+			"containers": []any{
+				map[string]any{"name": "app"},
+				map[string]any{"name": "sidecar"},
+			},
+		},
+	}}
+
+	met := PatchPreconditionsModel{
+		ResourceVersion: types.StringValue("42"),
+		Uid:             types.StringValue("8b6f0c3e"),
+		Field: []PatchPreconditionFieldModel{
+			{Path: types.StringValue(".spec.containers[0].name"), Value: types.StringValue("app")},
+			{Path: types.StringValue(".spec.replicas"), Value: types.StringValue("2")},
+			{Path: types.StringValue(".spec.missing"), Value: types.StringValue("")},
+		},
+	}
+	if err := checkPreconditions(obj, met); err != nil {
+		t.Fatalf("expected the preconditions to be met, got %s", err)
+	}
+
+	for _, tc := range []struct {
+		preconditions PatchPreconditionsModel
+		expected      string
+	}{
+		{
+			PatchPreconditionsModel{ResourceVersion: types.StringValue("41"), Uid: types.StringNull()},
+			`default/web: resource version is "42", expected "41"`,
+		},
+		{
+			PatchPreconditionsModel{ResourceVersion: types.StringNull(), Uid: types.StringValue("0")},
+			`default/web: uid is "8b6f0c3e", expected "0"`,
+		},
+		{
+			PatchPreconditionsModel{
+				ResourceVersion: types.StringNull(),
+				Uid:             types.StringNull(),
+				Field: []PatchPreconditionFieldModel{
+					{Path: types.StringValue(".spec.containers[0].name"), Value: types.StringValue("sidecar")},
+				},
+			},
+			`default/web: .spec.containers[0].name is "app", expected "sidecar"`,
+		},
+	} {
+		err := checkPreconditions(obj, tc.preconditions)
+		var unmet *preconditionError
+		if !errors.As(err, &unmet) {
+			t.Fatalf("expected a precondition error, got %v", err)
+		}
+		if err.Error() != tc.expected {
+			t.Errorf("expected %q, got %q", tc.expected, err)
+		}
+	}
+}
+
+func TestPinResourceVersion(t *testing.T) {
+	for _, tc := range []struct {
+		patchType, body, expected string
+	}{
+		{
+			"json",
+			`[{"op":"replace","path":"/spec/replicas","value":3}]`,
+			`[{"op":"test","path":"/metadata/resourceVersion","value":"42"},{"op":"replace","path":"/spec/replicas","value":3}]`,
+		},
+		{
+			"merge",
+			`{"spec":{"replicas":12345678901234567890}}`,
+			`{"metadata":{"resourceVersion":"42"},"spec":{"replicas":12345678901234567890}}`,
+		},
+		{
+			"apply",
+			`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a"}}`,
+			`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"a","resourceVersion":"42"}}`,
+		},
+	} {
+		body, err := pinResourceVersion(tc.patchType, []byte(tc.body), "42")
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if string(body) != tc.expected {
+			t.Errorf("expected %s, got %s", tc.expected, body)
+		}
+	}
+}
+
+func TestValidatePreconditions(t *testing.T) {
+	preconditions := PatchPreconditionsModel{
+		ResourceVersion: types.StringValue("42"),
+		Uid:             types.StringNull(),
+		Field: []PatchPreconditionFieldModel{
+			{Path: types.StringValue(".spec.containers[0"), Value: types.StringValue("app")},
+		},
+	}
+
+	diags := validatePreconditions(path.Root("preconditions").AtListIndex(0), preconditions, false)
+	if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != "Invalid JSONPath" {
+		t.Fatalf("expected an invalid JSONPath error, got %v", diags)
+	}
+
+	diags = validatePreconditions(path.Root("preconditions").AtListIndex(0), preconditions, true)
+	if diags.ErrorsCount() != 2 || diags.Errors()[0].Summary() != "Invalid Precondition" {
+		t.Fatalf("expected the resource version to be rejected with a selector, got %v", diags)
+	}
+}
@@ -8,22 +8,28 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/mitchellh/go-homedir"
-	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/clientcmd"
 
+	"k8s.io/apimachinery/pkg/runtime"
 	apimachineryschema "k8s.io/apimachinery/pkg/runtime/schema"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	restclient "k8s.io/client-go/rest"
@@ -43,6 +49,15 @@ type KubernetesPatchProvider struct {
 	version string
 }
 
+// ProviderData is the configured state of the provider shared with its
+// resources, data sources and ephemeral resources.
+type ProviderData struct {
+	Client         *KubernetesClient
+	Clients        *clientCache
+	IgnoreMetadata MetadataFilter
+	Retry          retryPolicy
+}
+
 // KubernetesPatchProviderModel describes the provider data model.
 type KubernetesPatchProviderModel struct {
 	Host     types.String `tfsdk:"host"`
@@ -66,9 +81,17 @@ type KubernetesPatchProviderModel struct {
 
 	ProxyURL types.String `tfsdk:"proxy_url"`
 
+	QPS            types.Float64 `tfsdk:"qps"`
+	Burst          types.Int64   `tfsdk:"burst"`
+	RequestTimeout types.String  `tfsdk:"request_timeout"`
+	UserAgent      types.String  `tfsdk:"user_agent"`
+	ContentType    types.String  `tfsdk:"content_type"`
+
 	IgnoreAnnotations types.List `tfsdk:"ignore_annotations"`
 	IgnoreLabels      types.List `tfsdk:"ignore_labels"`
 
+	Retry []RetryModel `tfsdk:"retry"`
+
 	Exec []struct {
 		APIVersion types.String            `tfsdk:"api_version"`
 		Command    types.String            `tfsdk:"command"`
@@ -150,14 +173,43 @@ func (p *KubernetesPatchProvider) Schema(ctx context.Context, req provider.Schem
 				Description: "URL to the proxy to be used for all API requests",
 				Optional:    true,
 			},
+			"qps": schema.Float64Attribute{
+				Description: "Maximum sustained rate of Kubernetes API requests per second, enforced by the client. Defaults to 5. A negative value disables client-side rate limiting. Delays caused by it are logged.",
+				Optional:    true,
+			},
+			"burst": schema.Int64Attribute{
+				Description: "Maximum number of Kubernetes API requests sent at once above `qps`. Defaults to 10.",
+				Optional:    true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"request_timeout": schema.StringAttribute{
+				Description: "Timeout of each Kubernetes API request, including its retries, e.g. `30s`. Defaults to no timeout.",
+				Optional:    true,
+				Validators: []validator.String{
+					durationValidator{},
+				},
+			},
+			"user_agent": schema.StringAttribute{
+				Description: "User agent sent with Kubernetes API requests, which identifies them in the audit logs of the API server. Defaults to `terraform-provider-kubepatch/<version>`.",
+				Optional:    true,
+			},
+			"content_type": schema.StringAttribute{
+				Description: "Content type of Kubernetes API requests made through typed clients; one of [application/json application/vnd.kubernetes.protobuf]. Protobuf is more efficient, but only supported for built-in resources, so patches, which apply to any resource, are always sent as JSON. Defaults to `application/json`.",
+				Optional:    true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(runtime.ContentTypeJSON, runtime.ContentTypeProtobuf),
+				},
+			},
 			"ignore_annotations": schema.ListAttribute{
 				ElementType: types.StringType,
-				Description: "List of Kubernetes metadata annotations to ignore across all resources handled by this provider for situations where external systems are managing certain resource annotations. Each item is a regular expression.",
+				Description: "List of Kubernetes metadata annotations to ignore across all resources handled by this provider for situations where external systems are managing certain resource annotations. Each item is a regular expression. Matching annotations are not reported as drift and are left out of computed objects such as `result` and `planned_object`, and the `object` and `values` of the `kubepatch_object` data source.",
 				Optional:    true,
 			},
 			"ignore_labels": schema.ListAttribute{
 				ElementType: types.StringType,
-				Description: "List of Kubernetes metadata labels to ignore across all resources handled by this provider for situations where external systems are managing certain resource labels. Each item is a regular expression.",
+				Description: "List of Kubernetes metadata labels to ignore across all resources handled by this provider for situations where external systems are managing certain resource labels. Each item is a regular expression. Matching labels are not reported as drift and are left out of computed objects such as `result` and `planned_object`, and the `object` and `values` of the `kubepatch_object` data source.",
 				Optional:    true,
 			},
 		},
@@ -182,6 +234,37 @@ func (p *KubernetesPatchProvider) Schema(ctx context.Context, req provider.Schem
 					},
 				},
 			},
+			"retry": schema.ListNestedBlock{
+				Description: "Retry Kubernetes API requests failing with transient errors: conflicts, throttling (honoring `Retry-After`), server errors such as etcd leader changes, connections refused while the API server restarts, and connections reset during reads, dry-runs and server-side applies, which are safe to repeat. Requests are retried with exponential backoff and jitter. Resources may override these settings with their own `retry` block.",
+				Validators: []validator.List{
+					listvalidator.SizeAtMost(1),
+				},
+				NestedObject: schema.NestedBlockObject{
+					Attributes: map[string]schema.Attribute{
+						"max_attempts": schema.Int64Attribute{
+							Description: "Number of times a request is sent, including the first time. 1 disables retries. Defaults to 5.",
+							Optional:    true,
+							Validators: []validator.Int64{
+								int64validator.AtLeast(1),
+							},
+						},
+						"initial_backoff": schema.StringAttribute{
+							Description: "Delay before the first retry, doubled with every further retry. Defaults to `1s`.",
+							Optional:    true,
+							Validators: []validator.String{
+								durationValidator{},
+							},
+						},
+						"max_backoff": schema.StringAttribute{
+							Description: "Upper bound of the delay between retries, unless the API server asks for a longer one through `Retry-After`. Defaults to `30s`.",
+							Optional:    true,
+							Validators: []validator.String{
+								durationValidator{},
+							},
+						},
+					},
+				},
+			},
 			"experiments": schema.ListNestedBlock{
 				Description: "Enable and disable experimental features.",
 				NestedObject: schema.NestedBlockObject{
@@ -207,42 +290,70 @@ func (p *KubernetesPatchProvider) Configure(ctx context.Context, req provider.Co
 		return
 	}
 
-	// Configuration values are now available.
-	// if data.Endpoint.IsNull() { /* ... */ }
-	restClient, diags := initializeConfiguration(data)
-	if diags.HasError() {
-		resp.Diagnostics.Append(diags...)
+	ignoreMetadata, diags := NewMetadataFilter(ctx, data.IgnoreAnnotations, data.IgnoreLabels)
+	resp.Diagnostics.Append(diags...)
+
+	if resp.Diagnostics.HasError() {
+		return
 	}
 
-	// create the clientset
-	clientset, err := kubernetes.NewForConfig(restClient)
+	retry, err := newRetryPolicy(defaultRetryPolicy, data.Retry)
 	if err != nil {
-		resp.Diagnostics.AddError("could not get clientset", err.Error())
+		resp.Diagnostics.AddAttributeError(path.Root("retry"), "Invalid Retry Configuration", err.Error())
 		return
 	}
 
-	// Example client configuration for data sources and resources
-	client := http.DefaultClient
-	resp.DataSourceData = client
-	resp.ResourceData = clientset
+	if data.UserAgent.IsNull() {
+		data.UserAgent = types.StringValue(fmt.Sprintf("terraform-provider-kubepatch/%s", p.version))
+	}
+
+	// Without a usable default cluster the client is nil, and only
+	// resources with a cluster block can be used.
+	clients := newClientCache(data, retry)
+	client, diags := clients.client(nil)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	providerData := &ProviderData{
+		Client:         client,
+		Clients:        clients,
+		IgnoreMetadata: ignoreMetadata,
+		Retry:          retry,
+	}
+	resp.DataSourceData = providerData
+	resp.ResourceData = providerData
+	resp.EphemeralResourceData = providerData
 }
 
 func (p *KubernetesPatchProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewPatchResource,
+		NewKustomizePatchesResource,
 	}
 }
 
 func (p *KubernetesPatchProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
-	return []func() ephemeral.EphemeralResource{}
+	return []func() ephemeral.EphemeralResource{
+		NewServiceAccountTokenEphemeralResource,
+	}
 }
 
 func (p *KubernetesPatchProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
-	return []func() datasource.DataSource{}
+	return []func() datasource.DataSource{
+		NewObjectDataSource,
+	}
 }
 
 func (p *KubernetesPatchProvider) Functions(ctx context.Context) []func() function.Function {
-	return []func() function.Function{}
+	return []func() function.Function{
+		NewJSONPatchFunction,
+		NewMergePatchFunction,
+		NewJSONPatchDiffFunction,
+		NewMergePatchDiffFunction,
+		NewStrategicMergeFunction,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -377,6 +488,31 @@ func initializeConfiguration(d KubernetesPatchProviderModel) (*restclient.Config
 		return nil, append(diags, nd)
 	}
 
+	if v := d.QPS.ValueFloat64Pointer(); v != nil {
+		cfg.QPS = float32(*v)
+	}
+	if v := d.Burst.ValueInt64Pointer(); v != nil {
+		cfg.Burst = int(*v)
+	}
+	if v := d.RequestTimeout.ValueStringPointer(); v != nil {
+		timeout, err := time.ParseDuration(*v)
+		if err != nil {
+			return nil, append(diags, diag.NewErrorDiagnostic(fmt.Sprintf("Failed to parse value for request_timeout: %s", *v), err.Error()))
+		}
+		cfg.Timeout = timeout
+	}
+	if v := d.UserAgent.ValueStringPointer(); v != nil {
+		cfg.UserAgent = *v
+	}
+	if v := d.ContentType.ValueStringPointer(); v != nil {
+		cfg.ContentType = *v
+		if *v == runtime.ContentTypeProtobuf {
+			// Resources without protobuf support, such as custom resources,
+			// are served as JSON.
+			cfg.AcceptContentTypes = strings.Join([]string{runtime.ContentTypeProtobuf, runtime.ContentTypeJSON}, ",")
+		}
+	}
+
 	return cfg, diags
 }
 
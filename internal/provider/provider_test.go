@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-testing/echoprovider"
 )
 
 // testAccProtoV6ProviderFactories is used to instantiate a provider during acceptance testing.
@@ -19,14 +22,14 @@ var testAccProtoV6ProviderFactories = map[string]func() (tfprotov6.ProviderServe
 	"kubepatch": providerserver.NewProtocol6WithError(New("test")()),
 }
 
-// testAccProtoV6ProviderFactoriesWithEcho includes the echo provider alongside the scaffolding provider.
+// testAccProtoV6ProviderFactoriesWithEcho includes the echo provider alongside the kubepatch provider.
 // It allows for testing assertions on data returned by an ephemeral resource during Open.
 // The echoprovider is used to arrange tests by echoing ephemeral data into the Terraform state.
 // This lets the data be referenced in test assertions with state checks.
-// var testAccProtoV6ProviderFactoriesWithEcho = map[string]func() (tfprotov6.ProviderServer, error){
-// 	"scaffolding": providerserver.NewProtocol6WithError(New("test")()),
-// 	"echo":        echoprovider.NewProviderServer(),
-// }
+var testAccProtoV6ProviderFactoriesWithEcho = map[string]func() (tfprotov6.ProviderServer, error){
+	"kubepatch": providerserver.NewProtocol6WithError(New("test")()),
+	"echo":      echoprovider.NewProviderServer(),
+}
 
 func testAccPreCheck(t *testing.T) {
 	// You can add code here to run prior to any test case execution, for example assertions
@@ -35,6 +38,12 @@ func testAccPreCheck(t *testing.T) {
 }
 
 func providerConfig(t *testing.T) string {
+	// The configuration is built before resource.Test gets a chance to skip
+	// the test, so skip here too rather than failing on missing variables.
+	if os.Getenv("TF_ACC") == "" {
+		t.Skip("Acceptance tests skipped unless env 'TF_ACC' set")
+	}
+
 	host := os.Getenv("KUBEPATCH_HOST")
 	if host == "" {
 		t.Fatal("KUBEPATCH_HOST must be set for acceptance tests")
@@ -61,3 +70,30 @@ provider "kubepatch" {
 }
 `, host, clusterCaCertificate, clientCertificate, clientKey)
 }
+
+func TestInitializeConfigurationClientSettings(t *testing.T) {
+	cfg, diags := initializeConfiguration(KubernetesPatchProviderModel{
+		Host:           types.StringValue("https://example.com"),
+		QPS:            types.Float64Value(50),
+		Burst:          types.Int64Value(100),
+		RequestTimeout: types.StringValue("30s"),
+		UserAgent:      types.StringValue("platform-team"),
+		ContentType:    types.StringValue("application/vnd.kubernetes.protobuf"),
+	})
+	if cfg == nil || diags.HasError() {
+		t.Fatalf("unexpected diagnostics: %v", diags)
+	}
+
+	if cfg.QPS != 50 || cfg.Burst != 100 {
+		t.Errorf("expected a rate limit of 50 QPS with bursts of 100, got %v and %d", cfg.QPS, cfg.Burst)
+	}
+	if cfg.Timeout != 30*time.Second {
+		t.Errorf("expected a timeout of 30s, got %s", cfg.Timeout)
+	}
+	if cfg.UserAgent != "platform-team" {
+		t.Errorf("expected the user agent to be set, got %q", cfg.UserAgent)
+	}
+	if cfg.ContentType != "application/vnd.kubernetes.protobuf" || cfg.AcceptContentTypes != "application/vnd.kubernetes.protobuf,application/json" {
+		t.Errorf("expected protobuf with a JSON fallback, got %q accepting %q", cfg.ContentType, cfg.AcceptContentTypes)
+	}
+}
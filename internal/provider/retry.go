@@ -0,0 +1,303 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// RetryModel describes the retry block of the provider and of resources.
+type RetryModel struct {
+	MaxAttempts    types.Int64  `tfsdk:"max_attempts"`
+	InitialBackoff types.String `tfsdk:"initial_backoff"`
+	MaxBackoff     types.String `tfsdk:"max_backoff"`
+}
+
+// retryPolicy controls how Kubernetes API requests failing with transient
+// errors are retried.
+type retryPolicy struct {
+	// maxAttempts is the number of times a request is sent, including the
+	// first time. 1 disables retries.
+	maxAttempts    int
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+}
+
+// defaultRetryPolicy is used when the provider has no retry block.
+var defaultRetryPolicy = retryPolicy{
+	maxAttempts:    5,
+	initialBackoff: time.Second,
+	maxBackoff:     30 * time.Second,
+}
+
+// newRetryPolicy returns base with the settings of the retry block, if any,
+// taking precedence.
+func newRetryPolicy(base retryPolicy, retry []RetryModel) (retryPolicy, error) {
+	if len(retry) == 0 {
+		return base, nil
+	}
+
+	policy := base
+	if v := retry[0].MaxAttempts; !v.IsNull() && !v.IsUnknown() {
+		policy.maxAttempts = int(v.ValueInt64())
+	}
+	for _, d := range []struct {
+		value  types.String
+		target *time.Duration
+	}{
+		{retry[0].InitialBackoff, &policy.initialBackoff},
+		{retry[0].MaxBackoff, &policy.maxBackoff},
+	} {
+		if d.value.IsNull() || d.value.IsUnknown() {
+			continue
+		}
+		duration, err := time.ParseDuration(d.value.ValueString())
+		if err != nil {
+			return base, err
+		}
+		*d.target = duration
+	}
+	return policy, nil
+}
+
+// backoff returns how long to wait before sending a request again after
+// attempt failed, counting from 1. The delay doubles with every attempt up to
+// maxBackoff, and is randomized between half and all of it so that clients
+// failing together do not retry together.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	delay := p.maxBackoff
+	if attempt < 32 {
+		if d := p.initialBackoff << (attempt - 1); d > 0 && d < delay {
+			delay = d
+		}
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+type retryPolicyKey struct{}
+type noConflictRetriesKey struct{}
+
+// withRetryPolicy returns a context whose Kubernetes API requests are retried
+// according to policy rather than the provider's policy.
+func withRetryPolicy(ctx context.Context, policy retryPolicy) context.Context {
+	return context.WithValue(ctx, retryPolicyKey{}, policy)
+}
+
+// withoutConflictRetries returns a context whose requests are not retried on
+// conflicts, for requests that are expected to conflict when the object
+// changed.
+func withoutConflictRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noConflictRetriesKey{}, true)
+}
+
+// retryTransport retries Kubernetes API requests failing with transient
+// errors: conflicts, throttling, server errors such as etcd leader changes,
+// connections refused while the API server restarts, and connections reset
+// during idempotent requests.
+type retryTransport struct {
+	base   http.RoundTripper
+	policy retryPolicy
+}
+
+// newRetryTransport returns a function wrapping transports with a
+// retryTransport, for use as rest.Config.WrapTransport.
+func newRetryTransport(policy retryPolicy) func(http.RoundTripper) http.RoundTripper {
+	return func(rt http.RoundTripper) http.RoundTripper {
+		return &retryTransport{base: rt, policy: policy}
+	}
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	policy := t.policy
+	if p, ok := ctx.Value(retryPolicyKey{}).(retryPolicy); ok {
+		policy = p
+	}
+	retryConflicts := ctx.Value(noConflictRetriesKey{}) == nil
+
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+
+		reason, retryAfter := retryReason(req, resp, err, retryConflicts)
+		if reason == "" || attempt >= policy.maxAttempts || (req.Body != nil && req.GetBody == nil) {
+			if reason != "" && resp != nil {
+				// client-go retries responses asking for a delay on its own,
+				// which would exceed maxAttempts.
+				resp.Header.Del("Retry-After")
+			}
+			return resp, err
+		}
+
+		delay := policy.backoff(attempt)
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		tflog.Info(ctx, "retrying Kubernetes API request", map[string]any{
+			"method":  req.Method,
+			"url":     req.URL.String(),
+			"reason":  reason,
+			"attempt": attempt,
+			"delay":   delay.String(),
+		})
+
+		if resp != nil {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req = req.Clone(ctx)
+			req.Body = body
+		}
+	}
+}
+
+// retryReason returns why req, which got resp or err, should be retried, or
+// the empty string if it should not. retryAfter is the delay the API server
+// asked for, if any.
+func retryReason(req *http.Request, resp *http.Response, err error, retryConflicts bool) (reason string, retryAfter time.Duration) {
+	if err != nil {
+		// A refused connection never reached the server, whereas a request
+		// whose connection was reset may have been applied, which only
+		// idempotent requests can afford to repeat.
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			return err.Error(), 0
+		}
+		if (errors.Is(err, syscall.ECONNRESET) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)) && idempotentRequest(req) {
+			return err.Error(), 0
+		}
+		return "", 0
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+		retryAfter = time.Duration(seconds) * time.Second
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return "too many requests", retryAfter
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		// Internal errors include etcd leader changes and timeouts.
+		status := responseStatus(resp)
+		if status.Message != "" {
+			return status.Message, retryAfter
+		}
+		return resp.Status, retryAfter
+	case http.StatusConflict:
+		if !retryConflicts {
+			return "", 0
+		}
+		// Field manager conflicts of server-side apply persist until the
+		// configuration or force changes.
+		status := responseStatus(resp)
+		if status.Details != nil {
+			for _, cause := range status.Details.Causes {
+				if cause.Type == metav1.CauseTypeFieldManagerConflict {
+					return "", 0
+				}
+			}
+		}
+		return fmt.Sprintf("conflict: %s", status.Message), retryAfter
+	}
+	return "", 0
+}
+
+// idempotentRequest reports whether sending req again has the same effect as
+// sending it once: reads, dry-runs and server-side applies, as opposed to
+// patches such as JSON patches appending to lists.
+func idempotentRequest(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		return true
+	case http.MethodPatch:
+		if slices.Contains(req.URL.Query()["dryRun"], metav1.DryRunAll) {
+			return true
+		}
+		contentType, _, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+		return k8stypes.PatchType(contentType) == k8stypes.ApplyYAMLPatchType
+	}
+	return false
+}
+
+// responseStatus decodes the Status in the body of resp, encoded as JSON or,
+// for requests of typed clients configured with content_type, protobuf. The
+// body is left readable by the caller.
+func responseStatus(resp *http.Response) metav1.Status {
+	var status metav1.Status
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return status
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == runtime.ContentTypeProtobuf {
+		_, _, _ = scheme.Codecs.UniversalDeserializer().Decode(b, nil, &status)
+		return status
+	}
+	_ = json.Unmarshal(b, &status)
+	return status
+}
+
+// Ensure the implementation satisfies the expected interfaces.
+var _ validator.String = durationValidator{}
+
+// durationValidator validates that a string is a duration as accepted by
+// time.ParseDuration.
+type durationValidator struct{}
+
+func (v durationValidator) Description(ctx context.Context) string {
+	return "value must be a duration such as \"30s\" or \"2m\""
+}
+
+func (v durationValidator) MarkdownDescription(ctx context.Context) string {
+	return "value must be a duration such as `30s` or `2m`"
+}
+
+func (v durationValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if _, err := time.ParseDuration(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid Duration", fmt.Sprintf("Expected a duration such as \"30s\" or \"2m\", got %q: %s", req.ConfigValue.ValueString(), err))
+	}
+}
@@ -0,0 +1,290 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+)
+
+// testRetryPolicy retries without noticeable delays.
+var testRetryPolicy = retryPolicy{maxAttempts: 3, initialBackoff: time.Millisecond, maxBackoff: 2 * time.Millisecond}
+
+func TestRetryTransport(t *testing.T) {
+	conflict := func(causes ...metav1.StatusCause) []byte {
+		b, _ := json.Marshal(metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonConflict,
+			Message: "the object has been modified",
+			Details: &metav1.StatusDetails{Causes: causes},
+			Code:    http.StatusConflict,
+		})
+		return b
+	}
+
+	protobufConflict := func(causes ...metav1.StatusCause) []byte {
+		info, _ := runtime.SerializerInfoForMediaType(scheme.Codecs.SupportedMediaTypes(), runtime.ContentTypeProtobuf)
+		b, err := runtime.Encode(scheme.Codecs.EncoderForVersion(info.Serializer, metav1.Unversioned), &metav1.Status{
+			Status:  metav1.StatusFailure,
+			Reason:  metav1.StatusReasonConflict,
+			Message: "Apply failed with 1 conflict",
+			Details: &metav1.StatusDetails{Causes: causes},
+			Code:    http.StatusConflict,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	for _, tc := range []struct {
+		name     string
+		ctx      func(context.Context) context.Context
+		statuses []int
+		body     []byte
+		header   http.Header
+		expected int
+		requests int
+	}{
+		{
+			name:     "server error",
+			statuses: []int{http.StatusServiceUnavailable, http.StatusInternalServerError, http.StatusOK},
+			expected: http.StatusOK,
+			requests: 3,
+		},
+		{
+			name:     "attempts exhausted",
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway, http.StatusOK},
+			expected: http.StatusBadGateway,
+			requests: 3,
+		},
+		{
+			name:     "throttled",
+			statuses: []int{http.StatusTooManyRequests, http.StatusOK},
+			header:   http.Header{"Retry-After": {"1"}},
+			expected: http.StatusOK,
+			requests: 2,
+		},
+		{
+			name:     "conflict",
+			statuses: []int{http.StatusConflict, http.StatusOK},
+			body:     conflict(),
+			expected: http.StatusOK,
+			requests: 2,
+		},
+		{
+			name:     "field manager conflict",
+			statuses: []int{http.StatusConflict, http.StatusOK},
+			body:     conflict(metav1.StatusCause{Type: metav1.CauseTypeFieldManagerConflict}),
+			expected: http.StatusConflict,
+			requests: 1,
+		},
+		{
+			name:     "protobuf field manager conflict",
+			statuses: []int{http.StatusConflict, http.StatusOK},
+			body:     protobufConflict(metav1.StatusCause{Type: metav1.CauseTypeFieldManagerConflict}),
+			header:   http.Header{"Content-Type": {runtime.ContentTypeProtobuf}},
+			expected: http.StatusConflict,
+			requests: 1,
+		},
+		{
+			name:     "conflict retries disabled",
+			ctx:      withoutConflictRetries,
+			statuses: []int{http.StatusConflict, http.StatusOK},
+			body:     conflict(),
+			expected: http.StatusConflict,
+			requests: 1,
+		},
+		{
+			name: "resource policy",
+			ctx: func(ctx context.Context) context.Context {
+				return withRetryPolicy(ctx, retryPolicy{maxAttempts: 1})
+			},
+			statuses: []int{http.StatusServiceUnavailable, http.StatusOK},
+			expected: http.StatusServiceUnavailable,
+			requests: 1,
+		},
+		{
+			name:     "client error",
+			statuses: []int{http.StatusNotFound, http.StatusOK},
+			expected: http.StatusNotFound,
+			requests: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != `{"spec":{}}` {
+					t.Errorf("expected the request body to be sent with every attempt, got %q", body)
+				}
+
+				status := tc.statuses[requests]
+				requests++
+				for k, v := range tc.header {
+					w.Header()[k] = v
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write(tc.body)
+			}))
+			defer server.Close()
+
+			ctx := context.Background()
+			if tc.ctx != nil {
+				ctx = tc.ctx(ctx)
+			}
+			req, err := http.NewRequestWithContext(ctx, http.MethodPatch, server.URL, strings.NewReader(`{"spec":{}}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			client := &http.Client{Transport: newRetryTransport(testRetryPolicy)(http.DefaultTransport)}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			defer resp.Body.Close()
+
+			if resp.StatusCode != tc.expected {
+				t.Errorf("expected status %d, got %d", tc.expected, resp.StatusCode)
+			}
+			if requests != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, requests)
+			}
+			if b, _ := io.ReadAll(resp.Body); string(b) != string(tc.body) {
+				t.Errorf("expected the response body to be readable, got %q", b)
+			}
+		})
+	}
+}
+
+func TestRetryTransportConnectionRefused(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	client := &http.Client{Transport: newRetryTransport(retryPolicy{maxAttempts: 3, initialBackoff: 20 * time.Millisecond, maxBackoff: time.Second})(http.DefaultTransport)}
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("expected an error")
+	}
+	// Two retries, after at least half of 20ms and of 40ms.
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("expected the request to be retried with backoff, returned after %s", elapsed)
+	}
+}
+
+func TestRetryTransportConnectionReset(t *testing.T) {
+	for _, tc := range []struct {
+		name        string
+		method      string
+		query       string
+		contentType string
+		requests    int
+	}{
+		{name: "get", method: http.MethodGet, requests: 3},
+		{name: "json patch", method: http.MethodPatch, contentType: "application/json-patch+json", requests: 1},
+		{name: "merge patch", method: http.MethodPatch, contentType: "application/merge-patch+json", requests: 1},
+		{name: "dry-run patch", method: http.MethodPatch, query: "?dryRun=All", contentType: "application/json-patch+json", requests: 3},
+		{name: "apply", method: http.MethodPatch, contentType: "application/apply-patch+yaml", requests: 3},
+		{name: "post", method: http.MethodPost, contentType: "application/json", requests: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				requests++
+				conn, _, err := http.NewResponseController(w).Hijack()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				conn.Close()
+			}))
+			defer server.Close()
+
+			var body io.Reader
+			if tc.method != http.MethodGet {
+				body = strings.NewReader(`{"spec":{}}`)
+			}
+			req, err := http.NewRequest(tc.method, server.URL+tc.query, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", tc.contentType)
+
+			// Keep-alives are disabled so that the transport does not
+			// retry on its own requests sent on a reused connection.
+			transport := http.DefaultTransport.(*http.Transport).Clone()
+			transport.DisableKeepAlives = true
+			client := &http.Client{Transport: newRetryTransport(testRetryPolicy)(transport)}
+			if _, err := client.Do(req); err == nil {
+				t.Fatal("expected an error")
+			}
+			if requests != tc.requests {
+				t.Errorf("expected %d requests, got %d", tc.requests, requests)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := retryPolicy{maxAttempts: 10, initialBackoff: time.Second, maxBackoff: 5 * time.Second}
+	for _, tc := range []struct {
+		attempt  int
+		min, max time.Duration
+	}{
+		{1, 500 * time.Millisecond, time.Second},
+		{2, time.Second, 2 * time.Second},
+		{3, 2 * time.Second, 4 * time.Second},
+		{4, 2500 * time.Millisecond, 5 * time.Second},
+		{100, 2500 * time.Millisecond, 5 * time.Second},
+	} {
+		for i := 0; i < 20; i++ {
+			if d := policy.backoff(tc.attempt); d < tc.min || d > tc.max {
+				t.Fatalf("expected the backoff of attempt %d to be within [%s, %s], got %s", tc.attempt, tc.min, tc.max, d)
+			}
+		}
+	}
+}
+
+func TestNewRetryPolicy(t *testing.T) {
+	policy, err := newRetryPolicy(defaultRetryPolicy, nil)
+	if err != nil || policy != defaultRetryPolicy {
+		t.Fatalf("expected the base policy, got %+v, %v", policy, err)
+	}
+
+	policy, err = newRetryPolicy(defaultRetryPolicy, []RetryModel{{
+		MaxAttempts:    types.Int64Value(2),
+		InitialBackoff: types.StringNull(),
+		MaxBackoff:     types.StringValue("1m"),
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := retryPolicy{maxAttempts: 2, initialBackoff: defaultRetryPolicy.initialBackoff, maxBackoff: time.Minute}
+	if policy != expected {
+		t.Fatalf("expected %+v, got %+v", expected, policy)
+	}
+
+	if _, err := newRetryPolicy(defaultRetryPolicy, []RetryModel{{MaxAttempts: types.Int64Null(), InitialBackoff: types.StringValue("soon"), MaxBackoff: types.StringNull()}}); err == nil {
+		t.Fatal("expected an invalid duration to be rejected")
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// revertEntry records the pre-patch value of a path touched by a patch.
+type revertEntry struct {
+	Path   string `json:"path"`
+	Exists bool   `json:"exists"`
+	Value  any    `json:"value,omitempty"`
+}
+
+// touchedPaths returns the paths of live that patch modifies. Paths inside
+// arrays are widened to the array itself, since array indices are not stable
+// across patches.
+func touchedPaths(live any, patchType string, patch []byte) ([][]string, error) {
+	var paths [][]string
+
+	switch patchType {
+	case "json":
+		var ops []jsonPatchOperation
+		if err := json.Unmarshal(patch, &ops); err != nil {
+			return nil, fmt.Errorf("could not decode JSON patch: %w", err)
+		}
+		for _, op := range ops {
+			var pointers []string
+			switch op.Op {
+			case "add", "replace", "remove", "copy":
+				pointers = []string{op.Path}
+			case "move":
+				pointers = []string{op.From, op.Path}
+			}
+			for _, pointer := range pointers {
+				path, err := parsePointer(pointer)
+				if err != nil {
+					return nil, err
+				}
+				if parentIsArray(live, path) {
+					path = path[:len(path)-1]
+				}
+				paths = append(paths, path)
+			}
+		}
+	default:
+		var obj map[string]any
+		if err := json.Unmarshal(patch, &obj); err != nil {
+			return nil, fmt.Errorf("could not decode patch: %w", err)
+		}
+		paths = objectPatchPaths(nil, obj)
+	}
+
+	return withoutDescendants(paths), nil
+}
+
+// objectPatchPaths walks a merge or strategic merge patch and returns the
+// paths of its leaves. Strategic merge directives are mapped onto the fields
+// they affect.
+func objectPatchPaths(prefix []string, obj map[string]any) [][]string {
+	var paths [][]string
+	for key, value := range obj {
+		switch {
+		case key == "$patch" || key == "$retainKeys":
+			// The directive applies to the whole object.
+			return [][]string{prefix}
+		case strings.HasPrefix(key, "$setElementOrder/"), strings.HasPrefix(key, "$deleteFromPrimitiveList/"):
+			_, field, _ := strings.Cut(key, "/")
+			paths = append(paths, appendPath(prefix, field))
+		default:
+			if nested, ok := value.(map[string]any); ok && len(nested) > 0 {
+				paths = append(paths, objectPatchPaths(appendPath(prefix, key), nested)...)
+			} else {
+				paths = append(paths, appendPath(prefix, key))
+			}
+		}
+	}
+	return paths
+}
+
+func appendPath(prefix []string, token string) []string {
+	path := make([]string, len(prefix), len(prefix)+1)
+	copy(path, prefix)
+	return append(path, token)
+}
+
+// withoutDescendants removes duplicate paths and paths that are covered by
+// one of their ancestors.
+func withoutDescendants(paths [][]string) [][]string {
+	sort.SliceStable(paths, func(i, j int) bool { return len(paths[i]) < len(paths[j]) })
+
+	var result [][]string
+	for _, path := range paths {
+		covered := false
+		for _, other := range result {
+			if isPrefix(other, path) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			result = append(result, path)
+		}
+	}
+	return result
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// captureRevert records the values of paths in live, the object as it is
+// before the patch is applied. Entries recorded by earlier applies are kept,
+// since they hold the values from before the first patch.
+func captureRevert(live any, paths [][]string, previous []revertEntry) ([]revertEntry, error) {
+	entries := append([]revertEntry{}, previous...)
+
+	for _, path := range paths {
+		// A patch creating a missing field also creates its missing parents,
+		// so record the outermost one that is absent.
+		for i := 1; i < len(path); i++ {
+			if _, ok := getPointer(live, path[:i]); !ok {
+				path = path[:i]
+				break
+			}
+		}
+
+		known := false
+		for _, entry := range entries {
+			entryPath, err := parsePointer(entry.Path)
+			if err != nil {
+				return nil, err
+			}
+			if isPrefix(entryPath, path) {
+				known = true
+				break
+			}
+		}
+		if known {
+			continue
+		}
+
+		value, exists := getPointer(live, path)
+		value = deepCopyJSON(value)
+
+		// Entries below this path hold values from before an earlier patch,
+		// so fold them into the value captured now.
+		var remaining []revertEntry
+		for _, entry := range entries {
+			entryPath, err := parsePointer(entry.Path)
+			if err != nil {
+				return nil, err
+			}
+			if !isPrefix(path, entryPath) {
+				remaining = append(remaining, entry)
+				continue
+			}
+			if exists {
+				value = setPointer(value, entryPath[len(path):], entry.Value, entry.Exists)
+			}
+		}
+
+		entries = append(remaining, revertEntry{
+			Path:   formatPointer(path),
+			Exists: exists,
+			Value:  value,
+		})
+	}
+
+	return entries, nil
+}
+
+// revertOperations builds the JSON patch that restores the recorded entries
+// on the live object.
+func revertOperations(live any, entries []revertEntry) ([]jsonPatchOperation, error) {
+	var ops []jsonPatchOperation
+
+	for _, entry := range entries {
+		path, err := parsePointer(entry.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			continue
+		}
+
+		_, exists := getPointer(live, path)
+		switch {
+		case entry.Exists && exists:
+			ops = append(ops, jsonPatchOperation{Op: "replace", Path: entry.Path, Value: entry.Value})
+		case entry.Exists:
+			// Parents removed since the patch was applied are recreated
+			// as objects.
+			i := len(path) - 1
+			value := entry.Value
+			for ; i > 0; i-- {
+				if _, ok := getPointer(live, path[:i]); ok {
+					break
+				}
+				value = map[string]any{path[i]: value}
+			}
+			ops = append(ops, jsonPatchOperation{Op: "add", Path: formatPointer(path[:i+1]), Value: value})
+		case exists:
+			ops = append(ops, jsonPatchOperation{Op: "remove", Path: entry.Path})
+		}
+	}
+
+	return ops, nil
+}
+
+// setPointer sets, or removes when exists is false, the value at path in
+// doc and returns the updated document.
+func setPointer(doc any, path []string, value any, exists bool) any {
+	if len(path) == 0 {
+		return value
+	}
+
+	switch v := doc.(type) {
+	case map[string]any:
+		if !exists && len(path) == 1 {
+			delete(v, path[0])
+			return v
+		}
+		child, ok := v[path[0]]
+		if !ok {
+			if !exists {
+				return v
+			}
+			if len(path) > 1 {
+				child = map[string]any{}
+			}
+		}
+		v[path[0]] = setPointer(child, path[1:], value, exists)
+		return v
+	case []any:
+		i, ok := arrayIndex(path[0], len(v))
+		if !ok || i >= len(v) {
+			return v
+		}
+		if !exists && len(path) == 1 {
+			return append(v[:i], v[i+1:]...)
+		}
+		v[i] = setPointer(v[i], path[1:], value, exists)
+		return v
+	default:
+		return doc
+	}
+}
+
+func deepCopyJSON(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, element := range v {
+			result[key] = deepCopyJSON(element)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, element := range v {
+			result[i] = deepCopyJSON(element)
+		}
+		return result
+	default:
+		return v
+	}
+}
+
+// normalizeJSON converts an object decoded by client-go, which uses int64 for
+// integers, into the generic form produced by encoding/json.
+func normalizeJSON(obj map[string]any) (any, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(b, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
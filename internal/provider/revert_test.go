@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	jsonpatch "gopkg.in/evanphx/json-patch.v4"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+func TestRevert(t *testing.T) {
+	original := `{
+  "metadata": {"name": "test", "labels": {"app": "test"}},
+  "spec": {
+    "replicas": 2,
+    "template": {"spec": {"containers": [
+      {"name": "app", "image": "app:1", "args": ["--a", "--b"]}
+    ]}}
+  }
+}`
+
+	testCases := map[string]struct {
+		patchType string
+		patches   []string
+	}{
+		"json replace": {
+			patchType: "json",
+			patches:   []string{`[{"op": "replace", "path": "/spec/template/spec/containers/0/args", "value": ["--c"]}]`},
+		},
+		"json array insert and remove": {
+			patchType: "json",
+			patches: []string{`[
+  {"op": "add", "path": "/spec/template/spec/containers/0/args/0", "value": "--first"},
+  {"op": "remove", "path": "/metadata/labels/app"},
+  {"op": "add", "path": "/metadata/annotations", "value": {"a": "b"}}
+]`},
+		},
+		"json move": {
+			patchType: "json",
+			patches:   []string{`[{"op": "move", "from": "/metadata/labels/app", "path": "/metadata/labels/name"}]`},
+		},
+		"merge": {
+			patchType: "merge",
+			patches:   []string{`{"metadata": {"labels": {"app": null, "team": "obs"}}, "spec": {"replicas": 3}}`},
+		},
+		"strategic": {
+			patchType: "strategic",
+			patches:   []string{`{"spec": {"template": {"spec": {"containers": [{"name": "sidecar", "image": "sidecar:1"}]}}}}`},
+		},
+		"merge applied twice with wider paths": {
+			patchType: "merge",
+			patches: []string{
+				`{"metadata": {"labels": {"team": "obs"}}}`,
+				`{"metadata": {"labels": {"team": "platform"}, "annotations": {"a": "b"}}}`,
+				`{"metadata": {"labels": null}}`,
+			},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var doc any
+			if err := json.Unmarshal([]byte(original), &doc); err != nil {
+				t.Fatal(err)
+			}
+			current := []byte(original)
+
+			var entries []revertEntry
+			for _, patch := range tc.patches {
+				paths, err := touchedPaths(doc, tc.patchType, []byte(patch))
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+				entries, err = captureRevert(doc, paths, entries)
+				if err != nil {
+					t.Fatalf("unexpected error: %s", err)
+				}
+
+				current = applyTestPatch(t, current, tc.patchType, patch)
+				doc = nil
+				if err := json.Unmarshal(current, &doc); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ops, err := revertOperations(doc, entries)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			b, err := json.Marshal(ops)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reverted := applyTestPatch(t, current, "json", string(b))
+
+			var expected, actual any
+			if err := json.Unmarshal([]byte(original), &expected); err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(reverted, &actual); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(expected, actual) {
+				t.Fatalf("expected reverted object to equal the original, got %s", reverted)
+			}
+		})
+	}
+}
+
+func applyTestPatch(t *testing.T, doc []byte, patchType, patch string) []byte {
+	t.Helper()
+
+	var result []byte
+	var err error
+	switch patchType {
+	case "json":
+		var p jsonpatch.Patch
+		p, err = jsonpatch.DecodePatch([]byte(patch))
+		if err == nil {
+			result, err = p.Apply(doc)
+		}
+	case "strategic":
+		dataStruct, _ := strategicDataStruct(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+		result, err = strategicpatch.StrategicMergePatch(doc, []byte(patch), dataStruct)
+	default:
+		result, err = jsonpatch.MergePatch(doc, []byte(patch))
+	}
+	if err != nil {
+		t.Fatalf("could not apply patch: %s", err)
+	}
+	return result
+}
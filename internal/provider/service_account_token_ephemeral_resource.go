@@ -0,0 +1,153 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Ensure provider defined types fully satisfy framework interfaces.
+var _ ephemeral.EphemeralResource = &ServiceAccountTokenEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithConfigure = &ServiceAccountTokenEphemeralResource{}
+
+func NewServiceAccountTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &ServiceAccountTokenEphemeralResource{}
+}
+
+// ServiceAccountTokenEphemeralResource defines the ephemeral resource
+// implementation.
+type ServiceAccountTokenEphemeralResource struct {
+	client *KubernetesClient
+}
+
+// ServiceAccountTokenEphemeralResourceModel describes the ephemeral resource
+// data model.
+type ServiceAccountTokenEphemeralResourceModel struct {
+	Namespace         types.String `tfsdk:"namespace"`
+	Name              types.String `tfsdk:"name"`
+	Audiences         types.List   `tfsdk:"audiences"`
+	ExpirationSeconds types.Int64  `tfsdk:"expiration_seconds"`
+
+	Token               types.String `tfsdk:"token"`
+	ExpirationTimestamp types.String `tfsdk:"expiration_timestamp"`
+}
+
+func (r *ServiceAccountTokenEphemeralResource) Metadata(ctx context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_service_account_token"
+}
+
+func (r *ServiceAccountTokenEphemeralResource) Schema(ctx context.Context, req ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		// This description is used by the documentation generator and the language server.
+		MarkdownDescription: "Requests a short-lived token for a ServiceAccount through the TokenRequest API. The token is never stored in the plan or state.",
+
+		Attributes: map[string]schema.Attribute{
+			"namespace": schema.StringAttribute{
+				MarkdownDescription: "Namespace of the ServiceAccount.",
+				Required:            true,
+			},
+			"name": schema.StringAttribute{
+				MarkdownDescription: "Name of the ServiceAccount.",
+				Required:            true,
+			},
+			"audiences": schema.ListAttribute{
+				ElementType:         types.StringType,
+				MarkdownDescription: "Intended audiences of the token. Defaults to the audiences of the API server.",
+				Optional:            true,
+			},
+			"expiration_seconds": schema.Int64Attribute{
+				MarkdownDescription: "Requested lifetime of the token in seconds, at least 600. The API server may issue a token with a different lifetime, as reported by `expiration_timestamp`. Defaults to one hour.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(600),
+				},
+			},
+			"token": schema.StringAttribute{
+				MarkdownDescription: "The issued token.",
+				Computed:            true,
+				Sensitive:           true,
+			},
+			"expiration_timestamp": schema.StringAttribute{
+				MarkdownDescription: "Time the token expires at, in RFC 3339 format.",
+				Computed:            true,
+			},
+		},
+	}
+}
+
+func (r *ServiceAccountTokenEphemeralResource) Configure(ctx context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	// Prevent panic if the provider has not been configured.
+	if req.ProviderData == nil {
+		return
+	}
+
+	providerData, ok := req.ProviderData.(*ProviderData)
+
+	if !ok {
+		resp.Diagnostics.AddError(
+			"Unexpected Ephemeral Resource Configure Type",
+			fmt.Sprintf("Expected *ProviderData, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+		)
+
+		return
+	}
+
+	r.client = providerData.Client
+}
+
+func (r *ServiceAccountTokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	var data ServiceAccountTokenEphemeralResourceModel
+
+	// Read Terraform config data into the model
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if r.client == nil {
+		resp.Diagnostics.AddError("Client Error", "Unable to request token, the provider has no usable Kubernetes configuration")
+		return
+	}
+
+	tokenRequest := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{
+			ExpirationSeconds: data.ExpirationSeconds.ValueInt64Pointer(),
+		},
+	}
+	resp.Diagnostics.Append(data.Audiences.ElementsAs(ctx, &tokenRequest.Spec.Audiences, false)...)
+
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	result, err := r.client.Clientset.CoreV1().ServiceAccounts(data.Namespace.ValueString()).CreateToken(ctx, data.Name.ValueString(), tokenRequest, metav1.CreateOptions{})
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to request token, got error: %s", err))
+		return
+	}
+
+	tflog.Trace(ctx, "issued service account token", map[string]any{
+		"namespace":  data.Namespace.ValueString(),
+		"name":       data.Name.ValueString(),
+		"expiration": result.Status.ExpirationTimestamp.UTC().Format(time.RFC3339),
+	})
+
+	data.Token = types.StringValue(result.Status.Token)
+	data.ExpirationTimestamp = types.StringValue(result.Status.ExpirationTimestamp.UTC().Format(time.RFC3339))
+
+	// Save data into ephemeral result data
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+}
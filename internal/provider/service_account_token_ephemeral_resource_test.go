@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/hashicorp/terraform-plugin-testing/helper/resource"
+	"github.com/hashicorp/terraform-plugin-testing/knownvalue"
+	"github.com/hashicorp/terraform-plugin-testing/statecheck"
+	"github.com/hashicorp/terraform-plugin-testing/tfjsonpath"
+	"github.com/hashicorp/terraform-plugin-testing/tfversion"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestAccServiceAccountTokenEphemeralResource(t *testing.T) {
+	resource.Test(t, resource.TestCase{
+		// Ephemeral resources are only available in 1.10 and later
+		TerraformVersionChecks: []tfversion.TerraformVersionCheck{
+			tfversion.SkipBelow(tfversion.Version1_10_0),
+		},
+		PreCheck:                 func() { testAccPreCheck(t) },
+		ProtoV6ProviderFactories: testAccProtoV6ProviderFactoriesWithEcho,
+		Steps: []resource.TestStep{
+			{
+				Config: providerConfig(t) + `
+ephemeral "kubepatch_service_account_token" "test" {
+  namespace          = "default"
+  name               = "default"
+  audiences          = ["vault"]
+  expiration_seconds = 600
+}
+
+provider "echo" {
+  data = ephemeral.kubepatch_service_account_token.test
+}
+
+resource "echo" "test" {}
+`,
+				ConfigStateChecks: []statecheck.StateCheck{
+					statecheck.ExpectKnownValue(
+						"echo.test",
+						tfjsonpath.New("data").AtMapKey("token"),
+						knownvalue.StringRegexp(regexp.MustCompile(`^[\w-]+\.[\w-]+\.[\w-]+$`)),
+					),
+					statecheck.ExpectKnownValue(
+						"echo.test",
+						tfjsonpath.New("data").AtMapKey("expiration_timestamp"),
+						knownvalue.NotNull(),
+					),
+				},
+			},
+		},
+	})
+}
+
+func TestServiceAccountTokenEphemeralResource(t *testing.T) {
+	t.Setenv("KUBE_CONFIG_PATHS", "")
+	server := newFakeAPIServer(t, &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "v1",
+		"kind":       "ServiceAccount",
+		"metadata":   map[string]any{"namespace": "default", "name": "deployer"},
+	}})
+
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+
+	provider, err := testAccProtoV6ProviderFactories["kubepatch"]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	schemas, err := provider.GetProviderSchema(ctx, &tfprotov6.GetProviderSchemaRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// dynamicValue encodes values, with the other attributes of schema null.
+	dynamicValue := func(schema *tfprotov6.Schema, values map[string]tftypes.Value) *tfprotov6.DynamicValue {
+		objectType := schema.ValueType().(tftypes.Object)
+		for name, typ := range objectType.AttributeTypes {
+			if _, ok := values[name]; !ok {
+				values[name] = tftypes.NewValue(typ, nil)
+			}
+		}
+		v, err := tfprotov6.NewDynamicValue(objectType, tftypes.NewValue(objectType, values))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &v
+	}
+
+	configureResp, err := provider.ConfigureProvider(ctx, &tfprotov6.ConfigureProviderRequest{
+		Config: dynamicValue(schemas.Provider, map[string]tftypes.Value{"host": tftypes.NewValue(tftypes.String, server.URL)}),
+	})
+	if err != nil || len(configureResp.Diagnostics) > 0 {
+		t.Fatalf("unexpected configure result: %v %v", err, configureResp.Diagnostics)
+	}
+
+	schema := schemas.EphemeralResourceSchemas["kubepatch_service_account_token"]
+	openResp, err := provider.OpenEphemeralResource(ctx, &tfprotov6.OpenEphemeralResourceRequest{
+		TypeName: "kubepatch_service_account_token",
+		Config: dynamicValue(schema, map[string]tftypes.Value{
+			"namespace":          tftypes.NewValue(tftypes.String, "default"),
+			"name":               tftypes.NewValue(tftypes.String, "deployer"),
+			"audiences":          tftypes.NewValue(tftypes.List{ElementType: tftypes.String}, []tftypes.Value{tftypes.NewValue(tftypes.String, "vault")}),
+			"expiration_seconds": tftypes.NewValue(tftypes.Number, 600),
+		}),
+	})
+	if err != nil || len(openResp.Diagnostics) > 0 {
+		t.Fatalf("unexpected open result: %v %v", err, openResp.Diagnostics)
+	}
+
+	tokens := server.issuedTokens()
+	if len(tokens) != 1 {
+		t.Fatalf("expected one TokenRequest, got %d", len(tokens))
+	}
+	spec := tokens[0].Spec
+	if !reflect.DeepEqual(spec.Audiences, []string{"vault"}) || spec.ExpirationSeconds == nil || *spec.ExpirationSeconds != 600 {
+		t.Errorf("expected a request for the vault audience expiring after 600 seconds, got %+v", spec)
+	}
+
+	result, err := openResp.Result.Unmarshal(schema.ValueType())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values map[string]tftypes.Value
+	if err := result.As(&values); err != nil {
+		t.Fatal(err)
+	}
+	var token, expiration string
+	if err := values["token"].As(&token); err != nil {
+		t.Fatal(err)
+	}
+	if err := values["expiration_timestamp"].As(&expiration); err != nil {
+		t.Fatal(err)
+	}
+	if token != "token-default-deployer-1" {
+		t.Errorf("expected the issued token, got %q", token)
+	}
+	if expected := fakeTokenIssued.Add(600 * time.Second).Format(time.RFC3339); expiration != expected {
+		t.Errorf("expected expiration %s, got %s", expected, expiration)
+	}
+
+	// The token is only returned as the ephemeral result, never kept in
+	// private state or logged.
+	if bytes.Contains(openResp.Private, []byte(token)) {
+		t.Error("expected the token to be left out of private state")
+	}
+	if strings.Contains(output.String(), token) {
+		t.Error("expected the token to be left out of the logs")
+	}
+	if !strings.Contains(output.String(), "issued service account token") {
+		t.Error("expected the issued token to be logged")
+	}
+}
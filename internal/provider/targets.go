@@ -0,0 +1,381 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// PatchSelectorModel selects the objects a patch applies to by their labels,
+// fields and name.
+type PatchSelectorModel struct {
+	LabelSelector types.String `tfsdk:"label_selector"`
+	FieldSelector types.String `tfsdk:"field_selector"`
+	NameRegex     types.String `tfsdk:"name_regex"`
+}
+
+// targetKey identifies a patched object within the state of a resource: its
+// name, prefixed with its namespace and a slash for namespaced resources.
+func targetKey(namespace, name string) string {
+	if namespace == "" {
+		return name
+	}
+	return namespace + "/" + name
+}
+
+// splitTargetKey is the inverse of targetKey.
+func splitTargetKey(key string) (namespace, name string) {
+	namespace, name, ok := strings.Cut(key, "/")
+	if !ok {
+		return "", key
+	}
+	return namespace, name
+}
+
+// objectKey returns the target key of obj.
+func objectKey(obj *unstructured.Unstructured) string {
+	return targetKey(obj.GetNamespace(), obj.GetName())
+}
+
+// selectsMany reports whether the patch applies to every object matching a
+// selector or namespace selection rather than to a single named object.
+func (data PatchResourceModel) selectsMany() bool {
+	return len(data.Selector) > 0 || data.spansNamespaces()
+}
+
+// spansNamespaces reports whether the patch targets objects across several
+// namespaces rather than in namespace.
+func (data PatchResourceModel) spansNamespaces() bool {
+	return data.AllNamespaces.ValueBool() || !data.NamespaceSelector.IsNull()
+}
+
+// targetName returns the name used in the identifier of the patch: the name
+// of the object, or "*" for a patch with a selector.
+func (data PatchResourceModel) targetName() string {
+	if len(data.Selector) > 0 {
+		return "*"
+	}
+	return data.Name.ValueString()
+}
+
+// targetNamespace returns the namespace used in the identifier of the patch:
+// namespace, or "*" for a patch spanning namespaces.
+func (data PatchResourceModel) targetNamespace() string {
+	if data.spansNamespaces() {
+		return "*"
+	}
+	return data.Namespace.ValueString()
+}
+
+// subresources returns the subresource the patch applies to, if any, as the
+// trailing arguments of the dynamic client's methods.
+func (data PatchResourceModel) subresources() []string {
+	if data.Subresource.IsNull() || data.Subresource.ValueString() == "" {
+		return nil
+	}
+	return []string{data.Subresource.ValueString()}
+}
+
+// checkSubresource checks that discovery reports the subresource the patch
+// applies to, if any, as patchable.
+func (r *PatchResource) checkSubresource(data PatchResourceModel, mapping *meta.RESTMapping) error {
+	if data.subresources() == nil {
+		return nil
+	}
+
+	subresources, err := r.client.Subresources(mapping)
+	if err != nil {
+		return fmt.Errorf("could not discover the subresources of %s: %w", mapping.Resource.GroupResource(), err)
+	}
+	if !slices.Contains(subresources, data.Subresource.ValueString()) {
+		return fmt.Errorf("%s has no patchable subresource %q; the cluster serves: %s", mapping.Resource.GroupResource(), data.Subresource.ValueString(), strings.Join(subresources, ", "))
+	}
+	return nil
+}
+
+// objectKind returns the kind of live, the object read from the target. It is
+// that of the resource unless a subresource such as scale, which has its own
+// kind, is targeted.
+func objectKind(mapping *meta.RESTMapping, live *unstructured.Unstructured) schema.GroupVersionKind {
+	if gvk := live.GroupVersionKind(); !gvk.Empty() {
+		return gvk
+	}
+	return mapping.GroupVersionKind
+}
+
+// namedTargetKey returns the target key of the named object of a patch
+// without a selector.
+func namedTargetKey(data PatchResourceModel, mapping *meta.RESTMapping) string {
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return data.Name.ValueString()
+	}
+	return targetKey(data.Namespace.ValueString(), data.Name.ValueString())
+}
+
+// mapping resolves the resource the patch applies to.
+func (r *PatchResource) mapping(data PatchResourceModel) (*meta.RESTMapping, error) {
+	if r.client == nil {
+		return nil, fmt.Errorf("the provider has no usable Kubernetes configuration")
+	}
+
+	mapping, err := r.client.RESTMapping(data.ApiVersion.ValueString(), data.Resource.ValueString())
+	if err != nil {
+		return nil, fmt.Errorf("could not resolve resource %q: %w", data.Resource.ValueString(), err)
+	}
+	return mapping, nil
+}
+
+// matchingObjects returns the objects the patch currently applies to, sorted
+// by target key. Without a selector or namespace selection this is the named
+// object, and a NotFound error is returned when it does not exist.
+func (r *PatchResource) matchingObjects(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping) ([]*unstructured.Unstructured, error) {
+	namespaced := mapping.Scope.Name() == meta.RESTScopeNameNamespace
+
+	var client dynamic.ResourceInterface
+	if namespaced && data.spansNamespaces() {
+		client = r.client.Dynamic.Resource(mapping.Resource)
+	} else {
+		var err error
+		client, err = r.client.ResourceInterface(mapping, data.Namespace.ValueString())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !data.selectsMany() {
+		obj, err := client.Get(ctx, data.Name.ValueString(), metav1.GetOptions{}, data.subresources()...)
+		if err != nil {
+			return nil, err
+		}
+		return []*unstructured.Unstructured{obj}, nil
+	}
+
+	var options metav1.ListOptions
+	var fieldSelectors []string
+	var nameRegex *regexp.Regexp
+	if len(data.Selector) > 0 {
+		selector := data.Selector[0]
+		options.LabelSelector = selector.LabelSelector.ValueString()
+		if v := selector.FieldSelector.ValueString(); v != "" {
+			fieldSelectors = append(fieldSelectors, v)
+		}
+		if !selector.NameRegex.IsNull() {
+			var err error
+			nameRegex, err = regexp.Compile(selector.NameRegex.ValueString())
+			if err != nil {
+				return nil, fmt.Errorf("invalid name_regex: %w", err)
+			}
+		}
+	} else {
+		// The named object in every targeted namespace.
+		fieldSelectors = append(fieldSelectors, fields.OneTermEqualSelector("metadata.name", data.Name.ValueString()).String())
+	}
+	options.FieldSelector = strings.Join(fieldSelectors, ",")
+
+	var namespaces map[string]bool
+	if namespaced && !data.NamespaceSelector.IsNull() {
+		var err error
+		namespaces, err = r.selectedNamespaces(ctx, data.NamespaceSelector.ValueString())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	list, err := client.List(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []*unstructured.Unstructured
+	for i := range list.Items {
+		obj := &list.Items[i]
+		if nameRegex != nil && !nameRegex.MatchString(obj.GetName()) {
+			continue
+		}
+		if namespaces != nil && !namespaces[obj.GetNamespace()] {
+			continue
+		}
+		if data.subresources() != nil {
+			// Subresources cannot be listed, so each one is read separately.
+			client, err := r.client.ResourceInterface(mapping, obj.GetNamespace())
+			if err != nil {
+				return nil, err
+			}
+			obj, err = client.Get(ctx, obj.GetName(), metav1.GetOptions{}, data.subresources()...)
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		objects = append(objects, obj)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objectKey(objects[i]) < objectKey(objects[j])
+	})
+
+	return objects, nil
+}
+
+// selectedNamespaces returns the names of the namespaces matching the label
+// selector.
+func (r *PatchResource) selectedNamespaces(ctx context.Context, selector string) (map[string]bool, error) {
+	list, err := r.client.Clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, fmt.Errorf("could not list namespaces: %w", err)
+	}
+
+	namespaces := make(map[string]bool, len(list.Items))
+	for _, namespace := range list.Items {
+		namespaces[namespace.Name] = true
+	}
+	return namespaces, nil
+}
+
+// plannedObjects returns the objects to patch on apply. With a selector these
+// are the objects found during plan, so that the applied set matches the
+// planned targets; objects matching since then are picked up by the next plan,
+// and planned objects deleted since are skipped.
+func (r *PatchResource) plannedObjects(ctx context.Context, data PatchResourceModel, mapping *meta.RESTMapping) ([]*unstructured.Unstructured, error) {
+	if !data.selectsMany() || data.Targets.IsUnknown() || data.Targets.IsNull() {
+		return r.matchingObjects(ctx, data, mapping)
+	}
+
+	var keys []string
+	if diags := data.Targets.ElementsAs(ctx, &keys, false); diags.HasError() {
+		return nil, fmt.Errorf("could not read targets")
+	}
+	return r.getObjects(ctx, mapping, keys, data.subresources()...)
+}
+
+// getObjects reads the objects with the given target keys, skipping those
+// that no longer exist.
+func (r *PatchResource) getObjects(ctx context.Context, mapping *meta.RESTMapping, keys []string, subresources ...string) ([]*unstructured.Unstructured, error) {
+	var objects []*unstructured.Unstructured
+	for _, key := range keys {
+		namespace, name := splitTargetKey(key)
+		client, err := r.client.ResourceInterface(mapping, namespace)
+		if err != nil {
+			return nil, err
+		}
+		obj, err := client.Get(ctx, name, metav1.GetOptions{}, subresources...)
+		if apierrors.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	return objects, nil
+}
+
+// targetKeys returns the target keys of objects.
+func targetKeys(objects []*unstructured.Unstructured) []string {
+	keys := make([]string, 0, len(objects))
+	for _, obj := range objects {
+		keys = append(keys, objectKey(obj))
+	}
+	return keys
+}
+
+// targetsValue returns keys as the value of the targets attribute.
+func targetsValue(keys []string) types.List {
+	values := make([]attr.Value, 0, len(keys))
+	for _, key := range keys {
+		values = append(values, types.StringValue(key))
+	}
+	return types.ListValueMust(types.StringType, values)
+}
+
+// targetChanges returns the keys in current but not in previous, and those in
+// previous but not in current.
+func targetChanges(previous, current []string) (added, removed []string) {
+	seen := make(map[string]bool, len(previous))
+	for _, key := range previous {
+		seen[key] = true
+	}
+	for _, key := range current {
+		if !seen[key] {
+			added = append(added, key)
+		}
+		delete(seen, key)
+	}
+	for _, key := range previous {
+		if seen[key] {
+			removed = append(removed, key)
+		}
+	}
+	return added, removed
+}
+
+// decodeRevertData decodes the revert_data attribute into the entries recorded
+// for each target key.
+func decodeRevertData(value types.String) (map[string][]revertEntry, error) {
+	entries := map[string][]revertEntry{}
+
+	v := strings.TrimSpace(value.ValueString())
+	if v == "" {
+		return entries, nil
+	}
+
+	if err := json.Unmarshal([]byte(v), &entries); err != nil {
+		return nil, fmt.Errorf("could not decode revert_data: %w", err)
+	}
+	return entries, nil
+}
+
+// encodeRevertData encodes the entries recorded for each target key as the
+// value of the revert_data attribute.
+func encodeRevertData(entries map[string][]revertEntry) (types.String, error) {
+	b, err := json.Marshal(entries)
+	if err != nil {
+		return types.StringNull(), err
+	}
+	return types.StringValue(string(b)), nil
+}
+
+// validateSelector checks the syntax of the criteria of a selector block.
+func validateSelector(p path.Path, selector PatchSelectorModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	if v := selector.LabelSelector; !v.IsNull() && !v.IsUnknown() {
+		if _, err := labels.Parse(v.ValueString()); err != nil {
+			diags.AddAttributeError(p.AtName("label_selector"), "Invalid Label Selector", err.Error())
+		}
+	}
+	if v := selector.FieldSelector; !v.IsNull() && !v.IsUnknown() {
+		if _, err := fields.ParseSelector(v.ValueString()); err != nil {
+			diags.AddAttributeError(p.AtName("field_selector"), "Invalid Field Selector", err.Error())
+		}
+	}
+	if v := selector.NameRegex; !v.IsNull() && !v.IsUnknown() {
+		if _, err := regexp.Compile(v.ValueString()); err != nil {
+			diags.AddAttributeError(p.AtName("name_regex"), "Invalid Regular Expression", err.Error())
+		}
+	}
+
+	return diags
+}
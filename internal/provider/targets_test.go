@@ -0,0 +1,97 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func TestTargetKey(t *testing.T) {
+	for _, tc := range []struct {
+		namespace, name, key string
+	}{
+		{"default", "test", "default/test"},
+		{"", "view", "view"},
+	} {
+		if key := targetKey(tc.namespace, tc.name); key != tc.key {
+			t.Errorf("expected key %q, got %q", tc.key, key)
+		}
+		namespace, name := splitTargetKey(tc.key)
+		if namespace != tc.namespace || name != tc.name {
+			t.Errorf("expected %q to split into %q and %q, got %q and %q", tc.key, tc.namespace, tc.name, namespace, name)
+		}
+	}
+}
+
+func TestTargetChanges(t *testing.T) {
+	added, removed := targetChanges([]string{"default/a", "default/b"}, []string{"default/b", "default/c"})
+	if !reflect.DeepEqual(added, []string{"default/c"}) {
+		t.Errorf("expected default/c to be added, got %v", added)
+	}
+	if !reflect.DeepEqual(removed, []string{"default/a"}) {
+		t.Errorf("expected default/a to be removed, got %v", removed)
+	}
+
+	added, removed = targetChanges(nil, []string{"default/a"})
+	if !reflect.DeepEqual(added, []string{"default/a"}) || removed != nil {
+		t.Errorf("expected only default/a to be added, got %v and %v", added, removed)
+	}
+}
+
+func TestDecodeRevertData(t *testing.T) {
+	entries, err := decodeRevertData(types.StringValue(`{"default/test": [{"path": "/spec/replicas", "exists": true, "value": 1}]}`))
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := map[string][]revertEntry{
+		"default/test": {{Path: "/spec/replicas", Exists: true, Value: float64(1)}},
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Fatalf("expected %v, got %v", expected, entries)
+	}
+
+	value, err := encodeRevertData(entries)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	entries, err = decodeRevertData(value)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(expected, entries) {
+		t.Fatalf("expected %v after a round trip, got %v", expected, entries)
+	}
+
+	entries, err = decodeRevertData(types.StringNull())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got %v", entries)
+	}
+}
+
+func TestValidateSelector(t *testing.T) {
+	diags := validateSelector(path.Root("selector").AtListIndex(0), PatchSelectorModel{
+		LabelSelector: types.StringValue("team=obs,tier!=batch"),
+		FieldSelector: types.StringValue("status.phase=Running"),
+		NameRegex:     types.StringValue("^fluent-bit-"),
+	})
+	if diags.HasError() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	diags = validateSelector(path.Root("selector").AtListIndex(0), PatchSelectorModel{
+		LabelSelector: types.StringValue("team in obs"),
+		FieldSelector: types.StringValue("status.phase"),
+		NameRegex:     types.StringValue("("),
+	})
+	if diags.ErrorsCount() != 3 {
+		t.Fatalf("expected 3 errors, got %v", diags)
+	}
+}
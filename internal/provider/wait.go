@@ -0,0 +1,285 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+// waitPollInterval is how often the object is read while waiting.
+var waitPollInterval = 2 * time.Second
+
+// PatchWaitModel describes the conditions to wait for after patching.
+type PatchWaitModel struct {
+	Rollout   types.Bool                `tfsdk:"rollout"`
+	Field     []PatchWaitFieldModel     `tfsdk:"field"`
+	Condition []PatchWaitConditionModel `tfsdk:"condition"`
+}
+
+// PatchWaitFieldModel waits for a JSONPath expression to evaluate to a value.
+type PatchWaitFieldModel struct {
+	Path  types.String `tfsdk:"path"`
+	Value types.String `tfsdk:"value"`
+}
+
+// PatchWaitConditionModel waits for an entry of status.conditions.
+type PatchWaitConditionModel struct {
+	Type   types.String `tfsdk:"type"`
+	Status types.String `tfsdk:"status"`
+}
+
+// validateWait validates the JSONPath expressions of the wait block at p, so
+// that malformed ones are reported before the object is patched.
+func validateWait(p path.Path, w PatchWaitModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+
+	for i, f := range w.Field {
+		if f.Path.IsUnknown() {
+			continue
+		}
+		if _, err := parseJSONPath(f.Path.ValueString()); err != nil {
+			diags.AddAttributeError(p.AtName("field").AtListIndex(i).AtName("path"), "Invalid JSONPath", err.Error())
+		}
+	}
+	return diags
+}
+
+// wait waits for the conditions of the wait block, if any, to be met by
+// every target object, until ctx is done.
+func (r *PatchResource) wait(ctx context.Context, data PatchResourceModel) error {
+	if len(data.Wait) == 0 {
+		return nil
+	}
+
+	mapping, err := r.mapping(data)
+	if err != nil {
+		return err
+	}
+
+	var keys []string
+	if diags := data.Targets.ElementsAs(ctx, &keys, false); diags.HasError() {
+		return fmt.Errorf("could not read targets")
+	}
+
+	for _, key := range keys {
+		namespace, name := splitTargetKey(key)
+		client, err := r.client.ResourceInterface(mapping, namespace)
+		if err != nil {
+			return err
+		}
+
+		err = waitFor(ctx, client, name, data.Wait[0])
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// waitFor polls the object until every condition of w is met or ctx is done.
+// On timeout the returned error includes the last observed status.
+func waitFor(ctx context.Context, client dynamic.ResourceInterface, name string, w PatchWaitModel) error {
+	var last *unstructured.Unstructured
+	var pending string
+
+	err := wait.PollUntilContextCancel(ctx, waitPollInterval, true, func(ctx context.Context) (bool, error) {
+		obj, err := client.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		last = obj
+
+		done, message, err := waitConditionsMet(obj, w)
+		if err != nil {
+			return false, err
+		}
+		if !done {
+			pending = message
+			tflog.Debug(ctx, "waiting for patched object", map[string]any{"name": name, "reason": message})
+		}
+		return done, nil
+	})
+
+	if err != nil && (errors.Is(err, context.DeadlineExceeded) || wait.Interrupted(err)) {
+		status := "none"
+		if last != nil {
+			if v, ok := last.Object["status"]; ok {
+				b, _ := json.Marshal(v)
+				status = string(b)
+			}
+		}
+		return fmt.Errorf("timed out waiting for %s: %s; last observed status: %s", name, pending, status)
+	}
+	return err
+}
+
+// waitConditionsMet reports whether obj satisfies every condition of w. When
+// it does not, the message describes the first unmet condition.
+func waitConditionsMet(obj *unstructured.Unstructured, w PatchWaitModel) (bool, string, error) {
+	if w.Rollout.ValueBool() {
+		done, message, err := rolloutComplete(obj)
+		if err != nil || !done {
+			return done, message, err
+		}
+	}
+
+	for _, f := range w.Field {
+		value, err := evaluateJSONPath(obj.Object, f.Path.ValueString())
+		if err != nil {
+			return false, "", err
+		}
+		if actual := formatValue(value); actual != f.Value.ValueString() {
+			return false, fmt.Sprintf("%s is %q, waiting for %q", f.Path.ValueString(), actual, f.Value.ValueString()), nil
+		}
+	}
+
+	for _, c := range w.Condition {
+		expected := "True"
+		if !c.Status.IsNull() {
+			expected = c.Status.ValueString()
+		}
+		actual, found := conditionStatus(obj, c.Type.ValueString())
+		if !found {
+			return false, fmt.Sprintf("condition %s is not reported yet", c.Type.ValueString()), nil
+		}
+		if actual != expected {
+			return false, fmt.Sprintf("condition %s is %s, waiting for %s", c.Type.ValueString(), actual, expected), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// formatValue renders a JSONPath result for comparison with a configured
+// value: strings as they are and everything else as compact JSON.
+func formatValue(value any) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	if value == nil {
+		return ""
+	}
+	return compactJSON(value)
+}
+
+func conditionStatus(obj *unstructured.Unstructured, conditionType string) (string, bool) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]any)
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		return status, true
+	}
+	return "", false
+}
+
+// rolloutComplete performs the same checks as kubectl rollout status for
+// Deployments, StatefulSets and DaemonSets.
+func rolloutComplete(obj *unstructured.Unstructured) (bool, string, error) {
+	generation := obj.GetGeneration()
+	observedGeneration, _, _ := unstructured.NestedInt64(obj.Object, "status", "observedGeneration")
+
+	switch obj.GetKind() {
+	case "Deployment":
+		if generation > observedGeneration {
+			return false, "waiting for the deployment spec update to be observed", nil
+		}
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, c := range conditions {
+			if condition, ok := c.(map[string]any); ok && condition["type"] == "Progressing" && condition["reason"] == "ProgressDeadlineExceeded" {
+				return false, "", fmt.Errorf("deployment %q exceeded its progress deadline", obj.GetName())
+			}
+		}
+
+		replicas := specReplicas(obj)
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+		total, _, _ := unstructured.NestedInt64(obj.Object, "status", "replicas")
+		available, _, _ := unstructured.NestedInt64(obj.Object, "status", "availableReplicas")
+		switch {
+		case updated < replicas:
+			return false, fmt.Sprintf("%d out of %d new replicas have been updated", updated, replicas), nil
+		case total > updated:
+			return false, fmt.Sprintf("%d old replicas are pending termination", total-updated), nil
+		case available < updated:
+			return false, fmt.Sprintf("%d of %d updated replicas are available", available, updated), nil
+		}
+		return true, "", nil
+	case "StatefulSet":
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+		if strategy != "" && strategy != "RollingUpdate" {
+			return true, "", nil
+		}
+		if observedGeneration == 0 || generation > observedGeneration {
+			return false, "waiting for the statefulset spec update to be observed", nil
+		}
+
+		replicas := specReplicas(obj)
+		ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+		if ready < replicas {
+			return false, fmt.Sprintf("%d of %d pods are ready", ready, replicas), nil
+		}
+
+		partition, found, _ := unstructured.NestedInt64(obj.Object, "spec", "updateStrategy", "rollingUpdate", "partition")
+		if found && partition > 0 {
+			updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+			if updated < replicas-partition {
+				return false, fmt.Sprintf("%d of %d partitioned pods have been updated", updated, replicas-partition), nil
+			}
+			return true, "", nil
+		}
+
+		updateRevision, _, _ := unstructured.NestedString(obj.Object, "status", "updateRevision")
+		currentRevision, _, _ := unstructured.NestedString(obj.Object, "status", "currentRevision")
+		if updateRevision != currentRevision {
+			updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedReplicas")
+			return false, fmt.Sprintf("%d pods at revision %s, waiting for the rest", updated, updateRevision), nil
+		}
+		return true, "", nil
+	case "DaemonSet":
+		strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+		if strategy != "" && strategy != "RollingUpdate" {
+			return true, "", nil
+		}
+		if generation > observedGeneration {
+			return false, "waiting for the daemonset spec update to be observed", nil
+		}
+
+		desired, _, _ := unstructured.NestedInt64(obj.Object, "status", "desiredNumberScheduled")
+		updated, _, _ := unstructured.NestedInt64(obj.Object, "status", "updatedNumberScheduled")
+		available, _, _ := unstructured.NestedInt64(obj.Object, "status", "numberAvailable")
+		switch {
+		case updated < desired:
+			return false, fmt.Sprintf("%d out of %d new pods have been updated", updated, desired), nil
+		case available < desired:
+			return false, fmt.Sprintf("%d of %d updated pods are available", available, desired), nil
+		}
+		return true, "", nil
+	default:
+		return false, "", fmt.Errorf("rollout waits are only supported for Deployments, StatefulSets and DaemonSets, not %s", obj.GetKind())
+	}
+}
+
+// specReplicas returns spec.replicas, which defaults to 1.
+func specReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
@@ -0,0 +1,181 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package provider
+
+import (
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestRolloutComplete(t *testing.T) {
+	testCases := map[string]struct {
+		obj       string
+		expected  bool
+		expectErr bool
+	}{
+		"deployment complete": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"generation": 2}, "spec": {"replicas": 2}, "status": {"observedGeneration": 2, "replicas": 2, "updatedReplicas": 2, "availableReplicas": 2}}`,
+			expected: true,
+		},
+		"deployment not observed": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"generation": 3}, "spec": {"replicas": 2}, "status": {"observedGeneration": 2, "replicas": 2, "updatedReplicas": 2, "availableReplicas": 2}}`,
+			expected: false,
+		},
+		"deployment old replicas pending": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"generation": 2}, "spec": {"replicas": 2}, "status": {"observedGeneration": 2, "replicas": 3, "updatedReplicas": 2, "availableReplicas": 2}}`,
+			expected: false,
+		},
+		"deployment unavailable": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"generation": 2}, "status": {"observedGeneration": 2, "replicas": 1, "updatedReplicas": 1}}`,
+			expected: false,
+		},
+		"deployment progress deadline exceeded": {
+			obj:       `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"generation": 2}, "status": {"observedGeneration": 2, "conditions": [{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"}]}}`,
+			expectErr: true,
+		},
+		"statefulset complete": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "StatefulSet", "metadata": {"generation": 1}, "spec": {"replicas": 3}, "status": {"observedGeneration": 1, "readyReplicas": 3, "updatedReplicas": 3, "currentRevision": "a", "updateRevision": "a"}}`,
+			expected: true,
+		},
+		"statefulset revision pending": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "StatefulSet", "metadata": {"generation": 1}, "spec": {"replicas": 3}, "status": {"observedGeneration": 1, "readyReplicas": 3, "updatedReplicas": 1, "currentRevision": "a", "updateRevision": "b"}}`,
+			expected: false,
+		},
+		"statefulset partition": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "StatefulSet", "metadata": {"generation": 1}, "spec": {"replicas": 3, "updateStrategy": {"type": "RollingUpdate", "rollingUpdate": {"partition": 2}}}, "status": {"observedGeneration": 1, "readyReplicas": 3, "updatedReplicas": 1, "currentRevision": "a", "updateRevision": "b"}}`,
+			expected: true,
+		},
+		"statefulset on delete": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "StatefulSet", "spec": {"updateStrategy": {"type": "OnDelete"}}}`,
+			expected: true,
+		},
+		"daemonset complete": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "DaemonSet", "metadata": {"generation": 4}, "status": {"observedGeneration": 4, "desiredNumberScheduled": 3, "updatedNumberScheduled": 3, "numberAvailable": 3}}`,
+			expected: true,
+		},
+		"daemonset updating": {
+			obj:      `{"apiVersion": "apps/v1", "kind": "DaemonSet", "metadata": {"generation": 4}, "status": {"observedGeneration": 4, "desiredNumberScheduled": 3, "updatedNumberScheduled": 2, "numberAvailable": 3}}`,
+			expected: false,
+		},
+		"unsupported kind": {
+			obj:       `{"apiVersion": "v1", "kind": "ConfigMap"}`,
+			expectErr: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			if err := obj.UnmarshalJSON([]byte(tc.obj)); err != nil {
+				t.Fatal(err)
+			}
+
+			actual, message, err := rolloutComplete(obj)
+			if tc.expectErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %t, got %t (%s)", tc.expected, actual, message)
+			}
+			if !actual && message == "" {
+				t.Fatal("expected a message describing the pending rollout")
+			}
+		})
+	}
+}
+
+func TestWaitConditionsMet(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	err := obj.UnmarshalJSON([]byte(`{
+  "apiVersion": "v1",
+  "kind": "Pod",
+  "metadata": {"name": "test"},
+  "status": {"phase": "Running", "podIP": "10.0.0.2", "conditions": [{"type": "Ready", "status": "True"}, {"type": "Initialized", "status": "False"}]}
+}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		wait     PatchWaitModel
+		expected bool
+	}{
+		"field matches": {
+			wait:     PatchWaitModel{Field: []PatchWaitFieldModel{{Path: types.StringValue(".status.phase"), Value: types.StringValue("Running")}}},
+			expected: true,
+		},
+		"field differs": {
+			wait:     PatchWaitModel{Field: []PatchWaitFieldModel{{Path: types.StringValue(".status.phase"), Value: types.StringValue("Succeeded")}}},
+			expected: false,
+		},
+		"missing field": {
+			wait:     PatchWaitModel{Field: []PatchWaitFieldModel{{Path: types.StringValue(".status.hostIP"), Value: types.StringValue("10.0.0.1")}}},
+			expected: false,
+		},
+		"condition defaults to true": {
+			wait:     PatchWaitModel{Condition: []PatchWaitConditionModel{{Type: types.StringValue("Ready"), Status: types.StringNull()}}},
+			expected: true,
+		},
+		"condition with status": {
+			wait:     PatchWaitModel{Condition: []PatchWaitConditionModel{{Type: types.StringValue("Initialized"), Status: types.StringValue("False")}}},
+			expected: true,
+		},
+		"condition not met": {
+			wait:     PatchWaitModel{Condition: []PatchWaitConditionModel{{Type: types.StringValue("Initialized"), Status: types.StringNull()}}},
+			expected: false,
+		},
+		"condition not reported": {
+			wait:     PatchWaitModel{Condition: []PatchWaitConditionModel{{Type: types.StringValue("ContainersReady"), Status: types.StringNull()}}},
+			expected: false,
+		},
+		"all conditions": {
+			wait: PatchWaitModel{
+				Field:     []PatchWaitFieldModel{{Path: types.StringValue(".status.phase"), Value: types.StringValue("Running")}},
+				Condition: []PatchWaitConditionModel{{Type: types.StringValue("Initialized"), Status: types.StringNull()}},
+			},
+			expected: false,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			actual, message, err := waitConditionsMet(obj, tc.wait)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if actual != tc.expected {
+				t.Fatalf("expected %t, got %t (%s)", tc.expected, actual, message)
+			}
+		})
+	}
+}
+
+func TestValidateWait(t *testing.T) {
+	w := PatchWaitModel{
+		Field: []PatchWaitFieldModel{
+			{Path: types.StringValue(".status.readyReplicas"), Value: types.StringValue("3")},
+			{Path: types.StringValue(".status.conditions[0"), Value: types.StringValue("True")},
+			{Path: types.StringUnknown(), Value: types.StringValue("True")},
+		},
+	}
+
+	diags := validateWait(path.Root("wait").AtListIndex(0), w)
+	if diags.ErrorsCount() != 1 || diags.Errors()[0].Summary() != "Invalid JSONPath" {
+		t.Fatalf("expected an invalid JSONPath error, got %v", diags)
+	}
+	expected := path.Root("wait").AtListIndex(0).AtName("field").AtListIndex(1).AtName("path")
+	if p := diags.Errors()[0].(diag.DiagnosticWithPath).Path(); !p.Equal(expected) {
+		t.Errorf("expected the error at %s, got %s", expected, p)
+	}
+}